@@ -0,0 +1,16 @@
+----------------------------------------------------
+-- Action bindings: key names as SDL spells them,
+-- "mouse:<button>" or "gamepad:<button>"
+----------------------------------------------------
+Input = {
+    actions = {
+        move_up = { "up", "gamepad:dpup" },
+        move_right = { "right", "gamepad:dpright" },
+        move_down = { "down", "gamepad:dpdown" },
+        move_left = { "left", "gamepad:dpleft" },
+        shoot = { "gamepad:a" },
+        pause = { "escape", "p", "gamepad:start" },
+        confirm = { "return", "gamepad:a" },
+        quit = { "q", "gamepad:back" }
+    }
+}
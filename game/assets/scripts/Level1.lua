@@ -14,6 +14,42 @@ else
     mapTextureAssetId = "terrain-texture-night"
 end
 
+----------------------------------------------------
+-- Enemies explode when killed and smoke while damaged
+----------------------------------------------------
+local enemyParticleEmitters = {
+    [0] = {
+        mode = "burst",
+        trigger = "ENTITY_KILLED",
+        count = 60,
+        lifetime = { min = 0.4, max = 1.2 },
+        speed = { min = 20, max = 90 },
+        spread = 360,
+        gravity = { x = 0, y = -10 },
+        sizes = { [0] = 3, [1] = 8, [2] = 12 },
+        colors = {
+            [0] = { r = 255, g = 240, b = 160, a = 255 },
+            [1] = { r = 255, g = 120, b = 20, a = 220 },
+            [2] = { r = 70, g = 70, b = 70, a = 0 }
+        }
+    },
+    [1] = {
+        mode = "continuous",
+        trigger = "ENTITY_DAMAGED",
+        rate = 12,
+        duration = 3,
+        lifetime = { min = 1, max = 1.6 },
+        speed = { min = 5, max = 12 },
+        angle = 270,
+        spread = 40,
+        sizes = { [0] = 4, [1] = 10 },
+        colors = {
+            [0] = { r = 90, g = 90, b = 90, a = 200 },
+            [1] = { r = 60, g = 60, b = 60, a = 0 }
+        }
+    }
+}
+
 Level1 = {
     ----------------------------------------------------
     -- Table to define the list of assets
@@ -52,8 +88,9 @@ Level1 = {
         [30] = { type="texture", id = "heliport-texture", file = "./assets/images/heliport.png" },
         [31] = { type="texture", id = "bullet-friendly-texture", file = "./assets/images/bullet-friendly.png" },
         [32] = { type="texture", id = "radar-texture", file = "./assets/images/radar.png" },
-        [33] = { type="sound", id = "blades-sound", file = "./assets/sounds/blades.wav" },
-        [33] = { type="font", id = "charriot-font", file = "./assets/fonts/charriot.ttf", fontSize = 14 }
+        [33] = { type="sound", id = "blades-sound", file = "./assets/sounds/helicopter.wav" },
+        [34] = { type="font", id = "charriot-font", file = "./assets/fonts/charriot.ttf", fontSize = 14 },
+        [35] = { type="animation", id = "chopper-animations", file = "./assets/images/chopper-spritesheet.json" }
     },
 
     ----------------------------------------------------
@@ -65,7 +102,42 @@ Level1 = {
         scale = 2,
         tileSize = 32,
         mapSizeX = 25,
-        mapSizeY = 20
+        mapSizeY = 20,
+        -- tiles enemies can't cross, or cross slowly, by their id in the map file
+        walkability = {
+            blocked = { "16", "17", "18", "19", "21" },
+            costs = { ["09"] = 3, ["10"] = 3, ["11"] = 3, ["12"] = 3, ["13"] = 3, ["14"] = 3, ["15"] = 3, ["22"] = 3 }
+        }
+    },
+
+    ----------------------------------------------------
+    -- table to define how the camera follows the player
+    ----------------------------------------------------
+    camera = {
+        follow = "player",
+        zoom = 1,
+        smoothTime = 0.15,
+        deadZone = {
+            width = 160,
+            height = 120
+        }
+    },
+
+    ----------------------------------------------------
+    -- table to define which collider tags interact
+    ----------------------------------------------------
+    collisions = {
+        masks = {
+            FRIENDLY_PROJECTILE = { "ENEMY" }
+        },
+        rules = {
+            [0] = { this = "PLAYER", that = "ENEMY", type = "PLAYER_ENEMY_COLLISION" },
+            [1] = { this = "PLAYER", that = "PROJECTILE", type = "PLAYER_PROJECTILE_COLLISION" },
+            [2] = { this = "ENEMY", that = "PROJECTILE", type = "ENEMY_PROJECTILE_COLLISION" },
+            [3] = { this = "PLAYER", that = "VEGETATION", type = "PLAYER_VEGETATION_COLLIDER" },
+            [4] = { this = "PLAYER", that = "LEVEL_COMPLETE", type = "PLAYER_LEVEL_COMPLETE_COLLISION" },
+            [5] = { this = "ENEMY", that = "FRIENDLY_PROJECTILE", type = "ENEMY_FRIENDLY_PROJECTILE_COLLISION" }
+        }
     },
 
     ----------------------------------------------------
@@ -92,15 +164,17 @@ Level1 = {
                 },
                 sprite = {
                     textureAssetId = "chopper-texture",
-                    animated = true,
-                    frameCount = 2,
-                    animationSpeed = 90,
-                    hasDirections = true,
+                    animationAssetId = "chopper-animations",
+                    clip = "DownAnimation",
                     fixed = false
                 },
                 collider = {
                     tag = "PLAYER"
                 },
+                health = {
+                    health = 3,
+                    invulnerability = 1.5
+                },
                 input = {
                     keyboard = {
                         up = "w",
@@ -109,6 +183,36 @@ Level1 = {
                         right = "d",
                         shoot = "space"
                     }
+                },
+                weapon = {
+                    textureAssetId = "bullet-friendly-texture",
+                    width = 4,
+                    height = 4,
+                    tag = "FRIENDLY_PROJECTILE",
+                    speed = 300,
+                    range = 400,
+                    fireRate = 4,
+                    poolSize = 16
+                },
+                particleEmitters = {
+                    [0] = {
+                        mode = "burst",
+                        trigger = "PROJECTILE_FIRED",
+                        count = 8,
+                        lifetime = { min = 0.05, max = 0.15 },
+                        speed = { min = 30, max = 80 },
+                        spread = 360,
+                        sizes = { [0] = 4, [1] = 1 },
+                        colors = {
+                            [0] = { r = 255, g = 255, b = 200, a = 255 },
+                            [1] = { r = 255, g = 180, b = 40, a = 0 }
+                        }
+                    }
+                },
+                soundEmitter = {
+                    soundAssetId = "blades-sound",
+                    mode = "loop",
+                    volume = 0.5
                 }
             }
         },
@@ -188,14 +292,28 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
-                projectileEmitter = {
-                    speed = 70,
-                    range = 300,
-                    angle = 180,
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
+                weapon = {
+                    textureAssetId = "projectile-texture",
                     width = 4,
                     height = 4,
-                    shouldLoop = true,
-                    textureAssetId = "projectile-texture"
+                    tag = "PROJECTILE",
+                    speed = 120,
+                    range = 300,
+                    fireRate = 0.75,
+                    poolSize = 4
+                },
+                ai = {
+                    turret = {
+                        range = 250,
+                        turnRate = 60,
+                        tolerance = 5,
+                        angle = 180
+                    }
                 }
             }
         },
@@ -224,6 +342,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 300,
@@ -260,6 +383,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 400,
@@ -296,6 +424,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -332,6 +465,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -368,6 +506,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -404,6 +547,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -440,6 +588,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -465,15 +618,36 @@ Level1 = {
                     width = 32,
                     height = 32,
                     scale = 1,
-                    rotation = 0
+                    rotation = 0,
+                    -- the texture shows the truck driving right; it turns to drive along its patrol
+                    faceVelocity = true,
+                    facing = 0
                 },
                 sprite = {
                     textureAssetId = "truck-right-texture",
                     animated = false
                 },
                 collider = {
-                    tag = "ENEMY"
+                    tag = "ENEMY",
+                    oriented = true
+                },
+                ai = {
+                    patrol = {
+                        speed = 20,
+                        loop = true,
+                        waypoints = {
+                            [0] = { x = 150, y = 450 },
+                            [1] = { x = 420, y = 450 },
+                            [2] = { x = 420, y = 500 },
+                            [3] = { x = 150, y = 500 }
+                        }
+                    }
+                },
+                health = {
+                    health = 2,
+                    score = 100
                 },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 1000,
@@ -510,6 +684,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -546,6 +725,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -582,6 +766,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -618,6 +807,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -654,6 +848,11 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                particleEmitters = enemyParticleEmitters,
                 projectileEmitter = {
                     speed = 70,
                     range = 500,
@@ -690,14 +889,31 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
-                projectileEmitter = {
-                    speed = 70,
-                    range = 1000,
-                    angle = 225,
-                    width = 4,
-                    height = 4,
-                    shouldLoop = true,
-                    textureAssetId = "projectile-texture"
+                health = {
+                    health = 2,
+                    score = 100
+                },
+                ai = {
+                    flee = {
+                        health = 0.5,
+                        radius = 200,
+                        speed = 35
+                    },
+                    chase = {
+                        radius = 150,
+                        speed = 20,
+                        stopDistance = 24
+                    },
+                    patrol = {
+                        speed = 15,
+                        loop = true,
+                        waypoints = {
+                            [0] = { x = 460, y = 445 },
+                            [1] = { x = 560, y = 445 },
+                            [2] = { x = 560, y = 505 },
+                            [3] = { x = 460, y = 505 }
+                        }
+                    }
                 }
             }
         },
@@ -726,6 +942,10 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
                 projectileEmitter = {
                     speed = 70,
                     range = 1000,
@@ -762,6 +982,10 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
                 projectileEmitter = {
                     speed = 70,
                     range = 1000,
@@ -798,6 +1022,10 @@ Level1 = {
                 collider = {
                     tag = "ENEMY"
                 },
+                health = {
+                    health = 2,
+                    score = 100
+                },
                 projectileEmitter = {
                     speed = 70,
                     range = 1000,
@@ -1517,25 +1745,97 @@ Level1 = {
             components = {
                 transform = {
                     position = {
-                        x = 720,
+                        x = 690,
                         y = 15
                     },
                     velocity = {
                         x = 0,
                         y = 0
                     },
-                    width = 64,
-                    height = 64,
+                    width = 96,
+                    height = 96,
                     scale = 1,
                     rotation = 0
                 },
-                sprite = {
-                    textureAssetId = "radar-texture",
-                    animated = true,
-                    frameCount = 8,
-                    animationSpeed = 150,
-                    hasDirections = false,
-                    fixed = true
+                minimap = {
+                    target = "player",
+                    range = 700,
+                    blips = {
+                        ENEMY = { r = 255, g = 60, b = 60 },
+                        LEVEL_COMPLETE = { r = 80, g = 160, b = 255 }
+                    }
+                }
+            }
+        },
+        [48] = {
+            name = "labelLevelName",
+            layer = 6,
+            components = {
+                textLabel = {
+                    x = 10,
+                    y = 10,
+                    text = "First Level...",
+                    fontAssetId = "charriot-font",
+                    color = {
+                        r = 255,
+                        g = 255,
+                        b = 255,
+                        a = 255
+                    }
+                }
+            }
+        },
+        [49] = {
+            name = "labelScore",
+            layer = 6,
+            components = {
+                textLabel = {
+                    x = 10,
+                    y = 30,
+                    text = "",
+                    fontAssetId = "charriot-font",
+                    color = {
+                        r = 255,
+                        g = 255,
+                        b = 255,
+                        a = 255
+                    }
+                }
+            }
+        },
+        [50] = {
+            name = "labelLives",
+            layer = 6,
+            components = {
+                textLabel = {
+                    x = 10,
+                    y = 50,
+                    text = "",
+                    fontAssetId = "charriot-font",
+                    color = {
+                        r = 255,
+                        g = 255,
+                        b = 255,
+                        a = 255
+                    }
+                }
+            }
+        },
+        [51] = {
+            name = "labelHealth",
+            layer = 6,
+            components = {
+                textLabel = {
+                    x = 10,
+                    y = 70,
+                    text = "",
+                    fontAssetId = "charriot-font",
+                    color = {
+                        r = 255,
+                        g = 255,
+                        b = 255,
+                        a = 255
+                    }
                 }
             }
         }
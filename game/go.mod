@@ -2,4 +2,7 @@ module engine
 
 go 1.24.0
 
-require github.com/veandco/go-sdl2 v0.4.40
+require (
+	github.com/veandco/go-sdl2 v0.4.40
+	github.com/yuin/gopher-lua v1.1.1
+)
@@ -0,0 +1,281 @@
+package engine
+
+import "math"
+
+// AI_ARRIVE_DISTANCE is how close, in pixels, a patrol has to get to a
+// waypoint before heading for the next one.
+const AI_ARRIVE_DISTANCE = 2
+
+// Behaviour is one thing an AI controlled entity does. Behaviours run in
+// order every step and only the first to call Steer moves the entity, so
+// earlier behaviours take priority over later ones.
+type Behaviour interface {
+	Update(ai *AIComponent, deltaTime float64)
+}
+
+// AIComponent runs an entity's behaviours against a target entity, looked up
+// by name. An entity no behaviour steers stands still.
+type AIComponent struct {
+	owner      *Entity
+	transform  *TransformComponent
+	sprite     *SpriteComponent
+	health     *HealthComponent
+	weapon     *WeaponComponent
+	targetName string
+	target     *Entity
+	behaviours []Behaviour
+	steered    bool
+}
+
+func NewAIComponent(targetName string, behaviours ...Behaviour) *AIComponent {
+	return &AIComponent{targetName: targetName, behaviours: behaviours}
+}
+
+func (c *AIComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *AIComponent) Initialize() {
+	c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	if c.owner.HasComponent(SPRITE_COMPONENT) {
+		c.sprite = c.owner.GetComponent(SPRITE_COMPONENT).(*SpriteComponent)
+	}
+	if c.owner.HasComponent(HEALTH_COMPONENT) {
+		c.health = c.owner.GetComponent(HEALTH_COMPONENT).(*HealthComponent)
+	}
+	if c.owner.HasComponent(WEAPON_COMPONENT) {
+		c.weapon = c.owner.GetComponent(WEAPON_COMPONENT).(*WeaponComponent)
+	}
+}
+
+func (c *AIComponent) Update(deltaTime float64) {
+	c.steered = false
+	for _, behaviour := range c.behaviours {
+		behaviour.Update(c, deltaTime)
+	}
+	if !c.steered {
+		c.transform.velocity = Vec2{0, 0}
+	}
+}
+
+func (c *AIComponent) Render(renderer Renderer) {}
+
+// Target is the entity the behaviours react to. It is looked up again once
+// the old one is destroyed, so a reloaded level's player is picked up.
+func (c *AIComponent) Target() *Entity {
+	if c.target == nil || !c.target.IsActive() {
+		c.target = c.owner.manager.GetEntityByName(c.targetName)
+	}
+	return c.target
+}
+
+// ToTarget is the offset from the entity's center to its target's, if there
+// is an enabled target to react to.
+func (c *AIComponent) ToTarget() (Vec2, bool) {
+	target := c.Target()
+	if target == nil || !target.IsEnabled() || !target.HasComponent(TRANSFORM_COMPONENT) {
+		return Vec2{}, false
+	}
+	return target.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent).Center().Sub(c.transform.Center()), true
+}
+
+// HealthFraction is how much of its health the entity has left, 1 without a
+// HealthComponent.
+func (c *AIComponent) HealthFraction() float64 {
+	if c.health == nil || c.health.MaxHealth() <= 0 {
+		return 1
+	}
+	return float64(c.health.Health()) / float64(c.health.MaxHealth())
+}
+
+// Steer moves the entity along direction at speed, facing the way it goes,
+// unless a behaviour with higher priority already steered it this step. A
+// zero direction holds the entity in place.
+func (c *AIComponent) Steer(direction Vec2, speed float64) {
+	if c.steered {
+		return
+	}
+	c.steered = true
+
+	length := direction.Length()
+	if length == 0 {
+		c.transform.velocity = Vec2{0, 0}
+		return
+	}
+	c.transform.velocity = Vec2{direction.X() / length * speed, direction.Y() / length * speed}
+
+	if c.sprite == nil {
+		return
+	}
+	switch {
+	case math.Abs(direction.X()) >= math.Abs(direction.Y()) && direction.X() > 0:
+		c.sprite.Play("RightAnimation")
+	case math.Abs(direction.X()) >= math.Abs(direction.Y()):
+		c.sprite.Play("LeftAnimation")
+	case direction.Y() > 0:
+		c.sprite.Play("DownAnimation")
+	default:
+		c.sprite.Play("UpAnimation")
+	}
+}
+
+// MoveTo steers the entity's center toward point, around blocked tiles when
+// the level has a path finder. It reports false, without steering, when no
+// route leads there.
+func (c *AIComponent) MoveTo(point Vec2, speed float64) bool {
+	center := c.transform.Center()
+	pathFinder := c.owner.manager.GetPathFinder()
+	if pathFinder == nil {
+		c.Steer(point.Sub(center), speed)
+		return true
+	}
+
+	path, ok := pathFinder.FindPath(center, point)
+	if !ok {
+		return false
+	}
+	for _, waypoint := range path {
+		if offset := waypoint.Sub(center); offset.Length() > AI_ARRIVE_DISTANCE {
+			c.Steer(offset, speed)
+			return true
+		}
+	}
+	c.Steer(Vec2{0, 0}, 0)
+	return true
+}
+
+// PatrolBehaviour walks the entity's top left corner through waypoints,
+// starting over from the first or, without loop, turning back at the ends.
+type PatrolBehaviour struct {
+	waypoints []Vec2
+	speed     float64
+	loop      bool
+	next      int
+	step      int
+}
+
+func NewPatrolBehaviour(waypoints []Vec2, speed float64, loop bool) *PatrolBehaviour {
+	return &PatrolBehaviour{waypoints: waypoints, speed: speed, loop: loop, step: 1}
+}
+
+func (b *PatrolBehaviour) Update(ai *AIComponent, deltaTime float64) {
+	if ai.steered || len(b.waypoints) == 0 {
+		return
+	}
+
+	if b.waypoints[b.next].Sub(ai.transform.position).Length() <= max(AI_ARRIVE_DISTANCE, b.speed*deltaTime) {
+		b.advance()
+	}
+	// Waypoints are where the corner goes, routes are found for the center
+	offset := b.waypoints[b.next].Sub(ai.transform.position)
+	if !ai.MoveTo(ai.transform.Center().Add(offset), b.speed) {
+		ai.Steer(offset, b.speed)
+	}
+}
+
+func (b *PatrolBehaviour) advance() {
+	n := len(b.waypoints)
+	if n == 1 {
+		return
+	}
+	if b.loop {
+		b.next = (b.next + 1) % n
+		return
+	}
+	if b.next+b.step < 0 || b.next+b.step >= n {
+		b.step = -b.step
+	}
+	b.next += b.step
+}
+
+// ChaseBehaviour heads for the target while it is within radius, stopping
+// stopDistance short of it. A target it has no route to is left alone.
+type ChaseBehaviour struct {
+	radius       float64
+	speed        float64
+	stopDistance float64
+}
+
+func NewChaseBehaviour(radius, speed, stopDistance float64) *ChaseBehaviour {
+	return &ChaseBehaviour{radius: radius, speed: speed, stopDistance: stopDistance}
+}
+
+func (b *ChaseBehaviour) Update(ai *AIComponent, deltaTime float64) {
+	if ai.steered {
+		return
+	}
+	offset, ok := ai.ToTarget()
+	if !ok || offset.Length() > b.radius {
+		return
+	}
+	if offset.Length() <= b.stopDistance {
+		ai.Steer(Vec2{0, 0}, 0)
+		return
+	}
+	ai.MoveTo(ai.transform.Center().Add(offset), b.speed)
+}
+
+// FleeBehaviour runs from the target once the entity's health drops to
+// healthFraction of its maximum, while the target is within radius. A radius
+// of 0 flees from any distance.
+type FleeBehaviour struct {
+	healthFraction float64
+	radius         float64
+	speed          float64
+}
+
+func NewFleeBehaviour(healthFraction, radius, speed float64) *FleeBehaviour {
+	return &FleeBehaviour{healthFraction: healthFraction, radius: radius, speed: speed}
+}
+
+func (b *FleeBehaviour) Update(ai *AIComponent, deltaTime float64) {
+	if ai.steered || ai.HealthFraction() > b.healthFraction {
+		return
+	}
+	offset, ok := ai.ToTarget()
+	if !ok || (b.radius > 0 && offset.Length() > b.radius) {
+		return
+	}
+	ai.Steer(Vec2{-offset.X(), -offset.Y()}, b.speed)
+}
+
+// TurretBehaviour turns the entity's weapon toward the target at turnRate
+// degrees a second and fires, at the weapon's fire rate, once it points
+// within tolerance degrees of the target and the target is within range. A
+// turnRate of 0 turns instantly. It never moves the entity.
+//
+// Only a WeaponComponent is aimed. A projectileEmitter keeps its fixed angle,
+// so turrets replace theirs with a weapon.
+type TurretBehaviour struct {
+	scope     float64
+	turnRate  float64
+	tolerance float64
+	angle     float64
+}
+
+func NewTurretBehaviour(scope, turnRate, tolerance, angle float64) *TurretBehaviour {
+	return &TurretBehaviour{scope: scope, turnRate: Radians(turnRate), tolerance: Radians(tolerance), angle: Radians(angle)}
+}
+
+func (b *TurretBehaviour) Update(ai *AIComponent, deltaTime float64) {
+	if ai.weapon == nil {
+		return
+	}
+	offset, ok := ai.ToTarget()
+	if !ok || offset.Length() > b.scope {
+		return
+	}
+
+	// Remainder wraps the difference into [-Pi, Pi], the short way round
+	turn := math.Remainder(math.Atan2(offset.Y(), offset.X())-b.angle, 2*math.Pi)
+	if b.turnRate > 0 {
+		turn = Clamp(turn, -b.turnRate*deltaTime, b.turnRate*deltaTime)
+	}
+	b.angle += turn
+	ai.weapon.Aim(Vec2{math.Cos(b.angle), math.Sin(b.angle)})
+
+	remaining := math.Remainder(math.Atan2(offset.Y(), offset.X())-b.angle, 2*math.Pi)
+	if math.Abs(remaining) <= b.tolerance {
+		ai.weapon.Fire()
+	}
+}
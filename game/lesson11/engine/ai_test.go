@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func addMover(manager *EntityManager, name string, position Vec2) (*Entity, *TransformComponent) {
+	entity := manager.AddEntity(name, ENEMY_LAYER)
+	return entity, entity.AddComponent(NewTransformComponent(position, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT).(*TransformComponent)
+}
+
+func TestPatrolBehaviour(t *testing.T) {
+	for _, test := range []struct {
+		loop bool
+		next []int
+	}{
+		{true, []int{1, 2, 0, 1, 2}},
+		{false, []int{1, 2, 1, 0, 1}},
+	} {
+		patrol := NewPatrolBehaviour([]Vec2{{0, 0}, {20, 0}, {20, 20}}, 20, test.loop)
+		next := []int{}
+		for range test.next {
+			patrol.advance()
+			next = append(next, patrol.next)
+		}
+		if !slices.Equal(next, test.next) {
+			t.Errorf("loop %v went through %v, want %v", test.loop, next, test.next)
+		}
+	}
+
+	// Walking the route, each leg takes about a second
+	manager := newTestManager()
+	walker, transform := addMover(manager, "walker", Vec2{0, 0})
+	patrol := NewPatrolBehaviour([]Vec2{{0, 0}, {20, 0}}, 20, false)
+	walker.AddComponent(NewAIComponent("player", patrol), AI_COMPONENT)
+	for range 30 {
+		manager.Update(0.05)
+	}
+	if patrol.next != 0 || transform.velocity != (Vec2{-20, 0}) || transform.position.X() > 12 {
+		t.Errorf("at %v going %v after 1.5s, want on the way back", transform.position, transform.velocity)
+	}
+}
+
+func TestChaseAndFlee(t *testing.T) {
+	manager := newTestManager()
+	_, target := addMover(manager, "player", Vec2{100, 0})
+	hunter, transform := addMover(manager, "hunter", Vec2{0, 0})
+	health := hunter.AddComponent(NewHealthComponent(4, 0, 0), HEALTH_COMPONENT).(*HealthComponent)
+	hunter.AddComponent(NewAIComponent("player", NewFleeBehaviour(0.5, 200, 35), NewChaseBehaviour(150, 30, 24)), AI_COMPONENT)
+
+	for _, test := range []struct {
+		name     string
+		target   Vec2
+		damage   int
+		velocity Vec2
+	}{
+		{"in range", Vec2{100, 0}, 0, Vec2{30, 0}},
+		{"out of range", Vec2{0, 200}, 0, Vec2{0, 0}},
+		{"close enough", Vec2{0, 20}, 0, Vec2{0, 0}},
+		{"hurt", Vec2{100, 0}, 2, Vec2{-35, 0}},
+		{"hurt out of range", Vec2{300, 0}, 0, Vec2{0, 0}},
+	} {
+		transform.position, target.position = Vec2{0, 0}, test.target
+		if test.damage > 0 {
+			health.TakeDamage(test.damage, nil)
+		}
+		manager.Update(0)
+		if transform.velocity != test.velocity {
+			t.Errorf("%s: velocity %v, want %v", test.name, transform.velocity, test.velocity)
+		}
+	}
+}
+
+func TestTurretBehaviour(t *testing.T) {
+	manager := newTestManager()
+	addMover(manager, "player", Vec2{0, 100})
+	turret, transform := addMover(manager, "turret", Vec2{0, 0})
+	pool := NewProjectilePool(manager, nil, 4, 4, "PROJECTILE", 1, 0)
+	weapon := turret.AddComponent(NewWeaponComponent(pool, 100, 300, 10), WEAPON_COMPONENT).(*WeaponComponent)
+	turret.AddComponent(NewAIComponent("player", NewTurretBehaviour(250, 90, 5, 0)), AI_COMPONENT)
+
+	// It takes a second to turn from facing right to facing the player below
+	for range 9 {
+		manager.Update(0.1)
+	}
+	if len(manager.Query(PROJECTILE_COMPONENT)) != 0 {
+		t.Error("fired before facing the player")
+	}
+	if angle := math.Atan2(weapon.direction.Y(), weapon.direction.X()) * 180 / math.Pi; math.Abs(angle-81) > 1e-6 {
+		t.Errorf("aiming at %v degrees after 0.9s, want 81", angle)
+	}
+	manager.Update(0.1)
+	if len(manager.Query(PROJECTILE_COMPONENT)) != 1 {
+		t.Error("didn't fire once facing the player")
+	}
+	if transform.velocity != (Vec2{0, 0}) {
+		t.Errorf("turret moved at %v", transform.velocity)
+	}
+}
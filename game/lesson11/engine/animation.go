@@ -1,7 +1,350 @@
 package engine
 
-type Animation struct {
-	index          uint
-	numFrames      int
-	animationSpeed int
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const DEFAULT_FRAME_DURATION = 0.1
+
+type AnimationMode int
+
+const (
+	ANIMATION_LOOP AnimationMode = iota
+	ANIMATION_PING_PONG
+	ANIMATION_ONCE
+)
+
+type AnimationFrame struct {
+	source   sdl.Rect
+	duration float64
+}
+
+type AnimationClip struct {
+	name   string
+	frames []AnimationFrame
+	mode   AnimationMode
+}
+
+func (c *AnimationClip) Name() string {
+	return c.name
+}
+
+func (c *AnimationClip) Len() int {
+	return len(c.frames)
+}
+
+// AnimationSet is every clip cut from one sprite sheet, keyed by name.
+type AnimationSet struct {
+	clips       map[string]*AnimationClip
+	defaultClip string
+}
+
+func NewAnimationSet() *AnimationSet {
+	return &AnimationSet{clips: make(map[string]*AnimationClip)}
+}
+
+func (s *AnimationSet) AddClip(clip *AnimationClip) {
+	if len(s.clips) == 0 {
+		s.defaultClip = clip.name
+	}
+	s.clips[clip.name] = clip
+}
+
+func (s *AnimationSet) Clip(name string) *AnimationClip {
+	return s.clips[name]
+}
+
+// NewGridAnimationSet cuts a sheet laid out with one clip per row, each of
+// numFrames frames of frameWidth x frameHeight.
+func NewGridAnimationSet(frameWidth, frameHeight, numFrames int, frameDuration float64, rowNames ...string) *AnimationSet {
+	if frameDuration <= 0 {
+		frameDuration = DEFAULT_FRAME_DURATION
+	}
+	set := NewAnimationSet()
+	for row, name := range rowNames {
+		clip := &AnimationClip{name: name, mode: ANIMATION_LOOP}
+		for column := range numFrames {
+			clip.frames = append(clip.frames, AnimationFrame{
+				source:   sdl.Rect{X: int32(column * frameWidth), Y: int32(row * frameHeight), W: int32(frameWidth), H: int32(frameHeight)},
+				duration: frameDuration,
+			})
+		}
+		set.AddClip(clip)
+	}
+	return set
+}
+
+type sheetRect struct {
+	X int32 `json:"x"`
+	Y int32 `json:"y"`
+	W int32 `json:"w"`
+	H int32 `json:"h"`
+}
+
+type sheetFrame struct {
+	Filename string    `json:"filename"`
+	Frame    sheetRect `json:"frame"`
+	Duration float64   `json:"duration"`
+}
+
+type sheetFile struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+			Repeat    string `json:"repeat"`
+		} `json:"frameTags"`
+	} `json:"meta"`
+	Animations map[string][]string `json:"animations"`
+}
+
+// LoadAnimationSet reads an Aseprite or TexturePacker JSON sheet. A sheet
+// without tags or animations plays all of its frames as one clip named "".
+func LoadAnimationSet(filename string) (*AnimationSet, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %v", err)
+	}
+
+	var sheet sheetFile
+	if err := json.Unmarshal(data, &sheet); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	frames, err := decodeSheetFrames(sheet.Frames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse frames of %s: %v", filename, err)
+	}
+
+	set := NewAnimationSet()
+	for _, tag := range sheet.Meta.FrameTags {
+		if tag.From < 0 || tag.To >= len(frames) || tag.From > tag.To {
+			return nil, fmt.Errorf("frame tag %s of %s is out of range", tag.Name, filename)
+		}
+		clip := &AnimationClip{name: tag.Name, mode: ANIMATION_LOOP}
+		for i := tag.From; i <= tag.To; i++ {
+			clip.frames = append(clip.frames, frames[i].animationFrame())
+		}
+		switch tag.Direction {
+		case "reverse", "pingpong_reverse":
+			for i, j := 0, len(clip.frames)-1; i < j; i, j = i+1, j-1 {
+				clip.frames[i], clip.frames[j] = clip.frames[j], clip.frames[i]
+			}
+		}
+		if tag.Direction == "pingpong" || tag.Direction == "pingpong_reverse" {
+			clip.mode = ANIMATION_PING_PONG
+		}
+		if repeat, err := strconv.Atoi(tag.Repeat); err == nil && repeat == 1 {
+			clip.mode = ANIMATION_ONCE
+		}
+		set.AddClip(clip)
+	}
+
+	if len(sheet.Animations) > 0 {
+		byName := make(map[string]sheetFrame, len(frames))
+		for _, frame := range frames {
+			byName[frame.Filename] = frame
+		}
+		names := make([]string, 0, len(sheet.Animations))
+		for name := range sheet.Animations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			clip := &AnimationClip{name: name, mode: ANIMATION_LOOP}
+			for _, frameName := range sheet.Animations[name] {
+				frame, ok := byName[frameName]
+				if !ok {
+					return nil, fmt.Errorf("animation %s of %s uses unknown frame %s", name, filename, frameName)
+				}
+				clip.frames = append(clip.frames, frame.animationFrame())
+			}
+			set.AddClip(clip)
+		}
+	}
+
+	if len(set.clips) == 0 && len(frames) > 0 {
+		clip := &AnimationClip{mode: ANIMATION_LOOP}
+		for _, frame := range frames {
+			clip.frames = append(clip.frames, frame.animationFrame())
+		}
+		set.AddClip(clip)
+	}
+	return set, nil
+}
+
+func (f sheetFrame) animationFrame() AnimationFrame {
+	duration := DEFAULT_FRAME_DURATION
+	if f.Duration > 0 {
+		duration = f.Duration / 1000
+	}
+	return AnimationFrame{source: sdl.Rect{X: f.Frame.X, Y: f.Frame.Y, W: f.Frame.W, H: f.Frame.H}, duration: duration}
+}
+
+// decodeSheetFrames keeps the order of hash layout frames, which tags index
+// into, by walking the JSON object's tokens instead of decoding into a map.
+func decodeSheetFrames(raw json.RawMessage) ([]sheetFrame, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if raw[0] == '[' {
+		var frames []sheetFrame
+		err := json.Unmarshal(raw, &frames)
+		return frames, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	var frames []sheetFrame
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var frame sheetFrame
+		if err := decoder.Decode(&frame); err != nil {
+			return nil, err
+		}
+		frame.Filename = key.(string)
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+type frameEventKey struct {
+	clip  string
+	frame int
+}
+
+// Animator plays clips of an AnimationSet on its own clock, so entities
+// animate independently and stop when the game stops updating them.
+type Animator struct {
+	set         *AnimationSet
+	clip        *AnimationClip
+	frame       int
+	step        int
+	elapsed     float64
+	speed       float64
+	finished    bool
+	frameEvents map[frameEventKey][]func()
+	onFinished  []func(clip string)
+}
+
+func NewAnimator(set *AnimationSet) *Animator {
+	animator := &Animator{set: set, speed: 1, frameEvents: make(map[frameEventKey][]func())}
+	animator.Play(set.defaultClip)
+	return animator
+}
+
+// Play switches to the named clip, restarting it only if it isn't already
+// the one playing.
+func (a *Animator) Play(name string) {
+	if a.clip != nil && a.clip.name == name && !a.finished {
+		return
+	}
+	clip := a.set.Clip(name)
+	if clip == nil || len(clip.frames) == 0 {
+		return
+	}
+	a.clip = clip
+	a.Restart()
+}
+
+func (a *Animator) Restart() {
+	a.frame, a.step, a.elapsed, a.finished = 0, 1, 0, false
+	a.fireFrameEvents()
+}
+
+func (a *Animator) SetSpeed(speed float64) {
+	a.speed = max(speed, 0)
+}
+
+func (a *Animator) CurrentClip() string {
+	if a.clip == nil {
+		return ""
+	}
+	return a.clip.name
+}
+
+func (a *Animator) CurrentFrame() int {
+	return a.frame
+}
+
+func (a *Animator) Finished() bool {
+	return a.finished
+}
+
+// OnFrame calls handler whenever the clip reaches the given frame.
+func (a *Animator) OnFrame(clip string, frame int, handler func()) {
+	key := frameEventKey{clip, frame}
+	a.frameEvents[key] = append(a.frameEvents[key], handler)
+}
+
+// OnFinished calls handler when a once clip plays its last frame out.
+func (a *Animator) OnFinished(handler func(clip string)) {
+	a.onFinished = append(a.onFinished, handler)
+}
+
+func (a *Animator) Source() (sdl.Rect, bool) {
+	if a.clip == nil {
+		return sdl.Rect{}, false
+	}
+	return a.clip.frames[a.frame].source, true
+}
+
+func (a *Animator) Update(deltaTime float64) {
+	if a.clip == nil || a.finished {
+		return
+	}
+	a.elapsed += deltaTime * a.speed
+
+	// A long frame time can skip several frames; each one still fires its events
+	for !a.finished && a.clip.frames[a.frame].duration > 0 && a.elapsed >= a.clip.frames[a.frame].duration {
+		a.elapsed -= a.clip.frames[a.frame].duration
+		a.advance()
+	}
+}
+
+func (a *Animator) advance() {
+	last := len(a.clip.frames) - 1
+	switch a.clip.mode {
+	case ANIMATION_LOOP:
+		a.frame = (a.frame + 1) % len(a.clip.frames)
+	case ANIMATION_PING_PONG:
+		if last == 0 {
+			break
+		}
+		if a.frame+a.step < 0 || a.frame+a.step > last {
+			a.step = -a.step
+		}
+		a.frame += a.step
+	case ANIMATION_ONCE:
+		if a.frame == last {
+			a.finished = true
+			for _, handler := range a.onFinished {
+				handler(a.clip.name)
+			}
+			return
+		}
+		a.frame++
+	}
+	a.fireFrameEvents()
+}
+
+func (a *Animator) fireFrameEvents() {
+	for _, handler := range a.frameEvents[frameEventKey{a.clip.name, a.frame}] {
+		handler()
+	}
 }
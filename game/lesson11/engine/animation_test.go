@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func testClip(name string, mode AnimationMode, frames int) *AnimationClip {
+	clip := &AnimationClip{name: name, mode: mode}
+	for i := range frames {
+		clip.frames = append(clip.frames, AnimationFrame{source: sdl.Rect{X: int32(i)}, duration: 1})
+	}
+	return clip
+}
+
+func TestAnimatorModes(t *testing.T) {
+	set := NewAnimationSet()
+	set.AddClip(testClip("loop", ANIMATION_LOOP, 3))
+	set.AddClip(testClip("pingpong", ANIMATION_PING_PONG, 3))
+	set.AddClip(testClip("once", ANIMATION_ONCE, 3))
+
+	for _, test := range []struct {
+		clip   string
+		frames []int
+	}{
+		{"loop", []int{0, 1, 2, 0, 1, 2, 0}},
+		{"pingpong", []int{0, 1, 2, 1, 0, 1, 2}},
+		{"once", []int{0, 1, 2, 2, 2, 2, 2}},
+	} {
+		animator := NewAnimator(set)
+		animator.Play(test.clip)
+		frames := []int{}
+		for range len(test.frames) {
+			frames = append(frames, animator.CurrentFrame())
+			animator.Update(1)
+		}
+		if !slices.Equal(frames, test.frames) {
+			t.Errorf("%s played %v, want %v", test.clip, frames, test.frames)
+		}
+		if animator.Finished() != (test.clip == "once") {
+			t.Errorf("%s finished: %v", test.clip, animator.Finished())
+		}
+	}
+}
+
+func TestAnimatorEvents(t *testing.T) {
+	set := NewAnimationSet()
+	set.AddClip(testClip("once", ANIMATION_ONCE, 4))
+	animator := NewAnimator(set)
+
+	var events []string
+	animator.OnFrame("once", 2, func() { events = append(events, "frame 2") })
+	animator.OnFinished(func(clip string) { events = append(events, "finished "+clip) })
+
+	// One long frame time skips frames without missing their events
+	animator.Update(10)
+	if !slices.Equal(events, []string{"frame 2", "finished once"}) {
+		t.Errorf("events %v", events)
+	}
+
+	// Playing a finished clip again restarts it
+	animator.Play("once")
+	if animator.Finished() || animator.CurrentFrame() != 0 {
+		t.Error("replaying a finished clip didn't restart it")
+	}
+}
+
+func TestLoadAnimationSet(t *testing.T) {
+	set, err := LoadAnimationSet("../../assets/images/chopper-spritesheet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	right := set.Clip("RightAnimation")
+	if right == nil || right.Len() != 2 {
+		t.Fatalf("RightAnimation is %+v, want 2 frames", right)
+	}
+	if frame := right.frames[0]; frame.source != (sdl.Rect{X: 0, Y: 32, W: 32, H: 32}) || frame.duration != 0.09 {
+		t.Errorf("first RightAnimation frame %+v", frame)
+	}
+}
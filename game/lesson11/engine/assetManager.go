@@ -2,64 +2,142 @@ package engine
 
 import (
 	"fmt"
+	"path/filepath"
 
-	"github.com/veandco/go-sdl2/img"
-	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 )
 
 type AssetManager struct {
-	renderer *sdl.Renderer
-	textures map[string]*sdl.Texture
-	fonts    map[string]*ttf.Font
+	renderer     Renderer
+	audio        AudioBackend
+	textures     map[string]Texture
+	fonts        map[string]*ttf.Font
+	sounds       map[string]Sound
+	music        map[string]Music
+	animations   map[string]*AnimationSet
+	atlases      map[string]*GlyphAtlas
+	textureFiles map[string]string
+	files        map[string]bool
+}
+
+func NewAssetManager(renderer Renderer, audio AudioBackend) *AssetManager {
+	return &AssetManager{
+		renderer:     renderer,
+		audio:        audio,
+		textures:     make(map[string]Texture),
+		fonts:        make(map[string]*ttf.Font),
+		sounds:       make(map[string]Sound),
+		music:        make(map[string]Music),
+		animations:   make(map[string]*AnimationSet),
+		atlases:      make(map[string]*GlyphAtlas),
+		textureFiles: make(map[string]string),
+		files:        make(map[string]bool),
+	}
 }
 
 func (m *AssetManager) ClearData() {
-	for k := range m.textures {
+	for k, texture := range m.textures {
+		texture.Destroy()
 		delete(m.textures, k)
 	}
-	for k := range m.fonts {
+	for k, atlas := range m.atlases {
+		atlas.Destroy()
+		delete(m.atlases, k)
+	}
+	for k, font := range m.fonts {
+		font.Close()
 		delete(m.fonts, k)
 	}
+	for k, sound := range m.sounds {
+		sound.Free()
+		delete(m.sounds, k)
+	}
+	// Music can't be freed while it plays
+	if len(m.music) > 0 && m.audio != nil {
+		m.audio.StopMusic()
+	}
+	for k, music := range m.music {
+		music.Free()
+		delete(m.music, k)
+	}
+	clear(m.animations)
+	clear(m.textureFiles)
+	clear(m.files)
+}
+
+// Uses reports whether any asset was loaded from filename.
+func (m *AssetManager) Uses(filename string) bool {
+	return m.files[filepath.Clean(filename)]
+}
+
+// ReloadTextures loads filename again into every texture made from it. The
+// textures stay the same objects, so sprites and tiles holding them pick up
+// the change. It reports whether any texture came from filename.
+func (m *AssetManager) ReloadTextures(filename string) (bool, error) {
+	filename = filepath.Clean(filename)
+	reloaded := false
+	for textureId, file := range m.textureFiles {
+		if file != filename {
+			continue
+		}
+		if err := m.renderer.ReloadTexture(m.textures[textureId], filename); err != nil {
+			return reloaded, fmt.Errorf("failed to reload texture %s: %v", textureId, err)
+		}
+		reloaded = true
+	}
+	return reloaded, nil
 }
 
+// AddTexture loads filename as textureId. Adding an id again, as rebuilding a
+// Tiled map does, reloads its texture in place rather than leaking it.
 func (m *AssetManager) AddTexture(textureId string, filename string) error {
-	texture, err := LoadTexture(filename, m.renderer)
-	if err != nil {
-		return err
+	if texture, ok := m.textures[textureId]; ok {
+		if err := m.renderer.ReloadTexture(texture, filename); err != nil {
+			return fmt.Errorf("failed to reload texture %s: %v", textureId, err)
+		}
+	} else {
+		texture, err := m.renderer.LoadTexture(filename)
+		if err != nil {
+			return err
+		}
+		m.textures[textureId] = texture
 	}
-	m.textures[textureId] = texture
+	m.textureFiles[textureId] = filepath.Clean(filename)
+	m.files[filepath.Clean(filename)] = true
 	return nil
 }
 
-func (m AssetManager) GetTexture(textureId string) *sdl.Texture {
+func (m AssetManager) GetTexture(textureId string) Texture {
 	return m.textures[textureId]
 }
 
-func LoadTexture(filename string, renderer *sdl.Renderer) (*sdl.Texture, error) {
-	surface, err := img.Load(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer surface.Free()
-
-	texture, err := renderer.CreateTextureFromSurface(surface)
+func (m *AssetManager) AddAnimations(animationsId string, filename string) error {
+	animations, err := LoadAnimationSet(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create texture: %v", err)
+		return err
 	}
-	return texture, nil
+	m.animations[animationsId] = animations
+	m.files[filepath.Clean(filename)] = true
+	return nil
 }
 
-func DrawTexture(texture *sdl.Texture, sourceRectangle, destinationRectangle sdl.Rect, flip sdl.RendererFlip, renderer *sdl.Renderer) {
-	renderer.CopyEx(texture, &sourceRectangle, &destinationRectangle, 0.0, nil, flip)
+func (m AssetManager) GetAnimations(animationsId string) *AnimationSet {
+	return m.animations[animationsId]
 }
 
 func (m *AssetManager) AddFont(fontId string, filename string, filesize int) error {
 	font, err := LoadFont(filename, filesize)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to load font %s: %v", filename, err)
+	}
+	if old, ok := m.fonts[fontId]; ok {
+		old.Close()
 	}
 	m.fonts[fontId] = font
+	m.files[filepath.Clean(filename)] = true
+	if atlas, ok := m.atlases[fontId]; ok {
+		return atlas.reset(font)
+	}
 	return nil
 }
 
@@ -67,10 +145,58 @@ func (m AssetManager) GetFont(fontId string) *ttf.Font {
 	return m.fonts[fontId]
 }
 
+// GetGlyphAtlas returns the font's glyph atlas, creating it on first use.
+func (m *AssetManager) GetGlyphAtlas(fontId string) (*GlyphAtlas, error) {
+	if atlas, ok := m.atlases[fontId]; ok {
+		return atlas, nil
+	}
+	font := m.fonts[fontId]
+	if font == nil {
+		return nil, fmt.Errorf("unknown font %q", fontId)
+	}
+	atlas, err := NewGlyphAtlas(m.renderer, font)
+	if err != nil {
+		return nil, err
+	}
+	m.atlases[fontId] = atlas
+	return atlas, nil
+}
+
 func LoadFont(filename string, fontsize int) (*ttf.Font, error) {
 	return ttf.OpenFont(filename, fontsize)
 }
 
-func DrawFont(texture *sdl.Texture, position sdl.Rect, renderer *sdl.Renderer) {
-	renderer.Copy(texture, nil, &position)
+func (m *AssetManager) AddSound(soundId string, filename string) error {
+	sound, err := m.audio.LoadSound(filename)
+	if err != nil {
+		return err
+	}
+	if old, ok := m.sounds[soundId]; ok {
+		old.Free()
+	}
+	m.sounds[soundId] = sound
+	m.files[filepath.Clean(filename)] = true
+	return nil
+}
+
+func (m AssetManager) GetSound(soundId string) Sound {
+	return m.sounds[soundId]
+}
+
+func (m *AssetManager) AddMusic(musicId string, filename string) error {
+	music, err := m.audio.LoadMusic(filename)
+	if err != nil {
+		return err
+	}
+	m.music[musicId] = music
+	m.files[filepath.Clean(filename)] = true
+	return nil
+}
+
+func (m AssetManager) GetMusic(musicId string) Music {
+	return m.music[musicId]
+}
+
+func (m AssetManager) GetAudio() AudioBackend {
+	return m.audio
 }
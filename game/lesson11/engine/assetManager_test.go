@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadTextures(t *testing.T) {
+	_, manager := newHeadlessManager(t, 16, 16)
+	assets := manager.assetManager
+	filename := filepath.Join(t.TempDir(), "tiles.png")
+	copyFile := func(from string) {
+		data, err := os.ReadFile(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	copyFile("testdata/tiled/terrain.png")
+	if err := assets.AddTexture("tiles", filename); err != nil {
+		t.Fatal(err)
+	}
+	texture := assets.GetTexture("tiles")
+	before := texture.(*imageTexture).image.RGBAAt(0, 0)
+
+	copyFile("testdata/tiled/props.png")
+	if reloaded, err := assets.ReloadTextures(filename); err != nil || !reloaded {
+		t.Fatalf("reloaded %v: %v", reloaded, err)
+	}
+	if assets.GetTexture("tiles") != texture || texture.(*imageTexture).image.RGBAAt(0, 0) == before {
+		t.Error("the texture wasn't reloaded in place")
+	}
+	if reloaded, _ := assets.ReloadTextures("other.png"); reloaded {
+		t.Error("reloaded a file no texture came from")
+	}
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/veandco/go-sdl2/mix"
+)
+
+// NULL_SOUND_LENGTH is how long every sound lasts on a NullAudioBackend.
+const NULL_SOUND_LENGTH = time.Second
+
+type Sound interface {
+	Free()
+}
+
+type Music interface {
+	Free()
+}
+
+// AudioBackend is what the engine needs from an audio device. Volume is in
+// [0, 1] and pan in [-1, 1], from full left to full right.
+type AudioBackend interface {
+	LoadSound(filename string) (Sound, error)
+	LoadMusic(filename string) (Music, error)
+	Play(sound Sound, loops int) (int, error)
+	// Plays counts the sounds started on channel, so whoever started one can
+	// tell whether the channel went on to play something else.
+	Plays(channel int) uint64
+	Playing(channel int) bool
+	SetVolume(channel int, volume float64)
+	SetPan(channel int, pan float64)
+	Stop(channel int)
+	PlayMusic(music Music, loops int) error
+	StopMusic()
+	Close()
+}
+
+type SDLAudioBackend struct {
+	plays map[int]uint64
+}
+
+type sdlSound struct {
+	chunk *mix.Chunk
+}
+
+func (s sdlSound) Free() {
+	s.chunk.Free()
+}
+
+type sdlMusic struct {
+	music *mix.Music
+}
+
+func (m sdlMusic) Free() {
+	m.music.Free()
+}
+
+func NewSDLAudioBackend() (*SDLAudioBackend, error) {
+	if err := mix.OpenAudio(mix.DEFAULT_FREQUENCY, mix.DEFAULT_FORMAT, mix.DEFAULT_CHANNELS, 1024); err != nil {
+		return nil, fmt.Errorf("failed to open audio: %s", err)
+	}
+	return &SDLAudioBackend{plays: make(map[int]uint64)}, nil
+}
+
+func (b *SDLAudioBackend) LoadSound(filename string) (Sound, error) {
+	chunk, err := mix.LoadWAV(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sound %s: %v", filename, err)
+	}
+	return sdlSound{chunk}, nil
+}
+
+func (b *SDLAudioBackend) LoadMusic(filename string) (Music, error) {
+	music, err := mix.LoadMUS(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load music %s: %v", filename, err)
+	}
+	return sdlMusic{music}, nil
+}
+
+func (b *SDLAudioBackend) Play(sound Sound, loops int) (int, error) {
+	channel, err := sound.(sdlSound).chunk.Play(-1, loops)
+	if err == nil {
+		b.plays[channel]++
+	}
+	return channel, err
+}
+
+func (b *SDLAudioBackend) Plays(channel int) uint64 {
+	return b.plays[channel]
+}
+
+func (b *SDLAudioBackend) Playing(channel int) bool {
+	return mix.Playing(channel) != 0
+}
+
+func (b *SDLAudioBackend) SetVolume(channel int, volume float64) {
+	mix.Volume(channel, int(Clamp(volume, 0, 1)*mix.MAX_VOLUME))
+}
+
+func (b *SDLAudioBackend) SetPan(channel int, pan float64) {
+	pan = Clamp(pan, -1, 1)
+	left := uint8(255 * Clamp(1-pan, 0, 1))
+	right := uint8(255 * Clamp(1+pan, 0, 1))
+	mix.SetPanning(channel, left, right)
+}
+
+func (b *SDLAudioBackend) Stop(channel int) {
+	mix.HaltChannel(channel)
+}
+
+func (b *SDLAudioBackend) PlayMusic(music Music, loops int) error {
+	return music.(sdlMusic).music.Play(loops)
+}
+
+func (b *SDLAudioBackend) StopMusic() {
+	mix.HaltMusic()
+}
+
+func (b *SDLAudioBackend) Close() {
+	mix.CloseAudio()
+}
+
+// NullAudioBackend plays nothing but keeps track of what would be playing, so
+// the engine runs without an audio device and sound logic can be inspected.
+type NullAudioBackend struct {
+	channels     map[int]*NullChannel
+	plays        map[int]uint64
+	playingMusic Music
+	now          func() time.Duration
+}
+
+type NullChannel struct {
+	sound  Sound
+	loops  int
+	volume float64
+	pan    float64
+	end    time.Duration
+}
+
+type nullSound struct {
+	filename string
+}
+
+func (s nullSound) Free() {}
+
+func NewNullAudioBackend() *NullAudioBackend {
+	start := time.Now()
+	return &NullAudioBackend{
+		channels: make(map[int]*NullChannel),
+		plays:    make(map[int]uint64),
+		now:      func() time.Duration { return time.Since(start) },
+	}
+}
+
+// SetClock makes sounds end by the given clock instead of the wall clock.
+func (b *NullAudioBackend) SetClock(now func() time.Duration) {
+	b.now = now
+}
+
+func (b *NullAudioBackend) LoadSound(filename string) (Sound, error) {
+	return nullSound{filename}, nil
+}
+
+func (b *NullAudioBackend) LoadMusic(filename string) (Music, error) {
+	return nullSound{filename}, nil
+}
+
+// expire frees the channels whose sound ended, like the mixer does.
+func (b *NullAudioBackend) expire() {
+	now := b.now()
+	for channel, c := range b.channels {
+		if c.loops >= 0 && now >= c.end {
+			delete(b.channels, channel)
+		}
+	}
+}
+
+func (b *NullAudioBackend) Play(sound Sound, loops int) (int, error) {
+	b.expire()
+	channel := 0
+	for b.channels[channel] != nil {
+		channel++
+	}
+	b.channels[channel] = &NullChannel{sound: sound, loops: loops, volume: 1, end: b.now() + NULL_SOUND_LENGTH*time.Duration(loops+1)}
+	b.plays[channel]++
+	return channel, nil
+}
+
+func (b *NullAudioBackend) Plays(channel int) uint64 {
+	return b.plays[channel]
+}
+
+func (b *NullAudioBackend) Playing(channel int) bool {
+	b.expire()
+	_, ok := b.channels[channel]
+	return ok
+}
+
+func (b *NullAudioBackend) Channel(channel int) *NullChannel {
+	b.expire()
+	return b.channels[channel]
+}
+
+func (b *NullAudioBackend) SetVolume(channel int, volume float64) {
+	if c, ok := b.channels[channel]; ok {
+		c.volume = Clamp(volume, 0, 1)
+	}
+}
+
+func (b *NullAudioBackend) SetPan(channel int, pan float64) {
+	if c, ok := b.channels[channel]; ok {
+		c.pan = Clamp(pan, -1, 1)
+	}
+}
+
+func (b *NullAudioBackend) Stop(channel int) {
+	delete(b.channels, channel)
+}
+
+func (b *NullAudioBackend) PlayMusic(music Music, loops int) error {
+	b.playingMusic = music
+	return nil
+}
+
+func (b *NullAudioBackend) StopMusic() {
+	b.playingMusic = nil
+}
+
+func (b *NullAudioBackend) Close() {
+	clear(b.channels)
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNullAudioBackend(t *testing.T) {
+	audio := NewNullAudioBackend()
+	var now time.Duration
+	audio.SetClock(func() time.Duration { return now })
+	sound, _ := audio.LoadSound("boom.wav")
+
+	once, _ := audio.Play(sound, 0)
+	twice, _ := audio.Play(sound, 1)
+	forever, _ := audio.Play(sound, -1)
+	if once == twice || twice == forever {
+		t.Fatalf("sounds share channels %d %d %d", once, twice, forever)
+	}
+
+	now = NULL_SOUND_LENGTH
+	if audio.Playing(once) || !audio.Playing(twice) || !audio.Playing(forever) {
+		t.Error("only the sound played once should have ended")
+	}
+	now = 10 * NULL_SOUND_LENGTH
+	if audio.Playing(twice) || !audio.Playing(forever) {
+		t.Error("only the looping sound should still play")
+	}
+
+	// Freed channels are handed out again
+	again, _ := audio.Play(sound, 0)
+	if again != once || audio.Plays(again) != 2 {
+		t.Errorf("replayed on channel %d with %d plays, want channel %d with 2", again, audio.Plays(again), once)
+	}
+}
+
+func TestSoundEmitter(t *testing.T) {
+	audio := NewNullAudioBackend()
+	var now time.Duration
+	audio.SetClock(func() time.Duration { return now })
+	manager := newTestManager()
+	manager.assetManager = NewAssetManager(nil, audio)
+	if err := manager.assetManager.AddSound("boom", "boom.wav"); err != nil {
+		t.Fatal(err)
+	}
+
+	first := manager.AddEntity("first", ENEMY_LAYER)
+	emitter := first.AddComponent(NewSoundEmitterComponent("boom", SOUND_ONE_SHOT, 0, 1, 0), SOUND_EMITTER_COMPONENT).(*SoundEmitterComponent)
+	channel := emitter.channel
+	if !audio.Playing(channel) {
+		t.Fatal("one shot didn't play")
+	}
+
+	now = NULL_SOUND_LENGTH
+	manager.Update(0)
+	if emitter.channel != -1 {
+		t.Errorf("emitter still holds channel %d after its sound ended", emitter.channel)
+	}
+
+	// The mixer gives the channel to another sound, which the first emitter
+	// mustn't stop
+	second := manager.AddEntity("second", ENEMY_LAYER)
+	other := second.AddComponent(NewSoundEmitterComponent("boom", SOUND_LOOP, 0, 0.5, 0), SOUND_EMITTER_COMPONENT).(*SoundEmitterComponent)
+	if other.channel != channel {
+		t.Fatalf("looping sound got channel %d, want the freed %d", other.channel, channel)
+	}
+	first.Destroy()
+	manager.Update(0)
+	if !audio.Playing(channel) || audio.Channel(channel).volume != 0.5 {
+		t.Error("destroying the first emitter touched the second one's channel")
+	}
+
+	second.Destroy()
+	manager.Update(0)
+	if audio.Playing(channel) {
+		t.Error("destroying the looping emitter didn't stop it")
+	}
+}
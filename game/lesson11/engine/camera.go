@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	CAMERA_SMOOTH_TIME      = 0.15
+	CAMERA_MAX_SHAKE_OFFSET = 24
+	CAMERA_TRAUMA_DECAY     = 1.5
+)
+
+// Camera is the view onto the world, in world pixels; zoom scales them to
+// screen pixels.
+type Camera struct {
+	center         Vec2
+	previous       Vec2
+	alpha          float64
+	velocity       Vec2
+	viewportWidth  int
+	viewportHeight int
+	zoom           float64
+	target         *Entity
+	deadZoneWidth  float64
+	deadZoneHeight float64
+	smoothTime     float64
+	boundsWidth    float64
+	boundsHeight   float64
+	trauma         float64
+	shakeOffset    Vec2
+}
+
+func NewCamera(viewportWidth, viewportHeight int) *Camera {
+	center := Vec2{float64(viewportWidth) / 2, float64(viewportHeight) / 2}
+	return &Camera{
+		center:         center,
+		previous:       center,
+		viewportWidth:  viewportWidth,
+		viewportHeight: viewportHeight,
+		zoom:           1,
+		smoothTime:     CAMERA_SMOOTH_TIME,
+	}
+}
+
+func (c *Camera) Follow(target *Entity) {
+	c.target = target
+}
+
+// SnapToTarget centers on the target immediately, e.g. after a level loads.
+func (c *Camera) SnapToTarget() {
+	if target, ok := c.targetCenter(); ok {
+		c.center = target
+		c.velocity = Vec2{0, 0}
+		c.clampToBounds()
+		c.previous = c.center
+	}
+}
+
+// SetDeadZone sets the size, in screen pixels, of the box around the center
+// the target can move in without the camera following.
+func (c *Camera) SetDeadZone(width, height int) {
+	c.deadZoneWidth, c.deadZoneHeight = float64(width), float64(height)
+}
+
+// SetSmoothTime sets roughly how many seconds the camera takes to catch up;
+// 0 follows rigidly.
+func (c *Camera) SetSmoothTime(seconds float64) {
+	c.smoothTime = max(seconds, 0)
+}
+
+// SetBounds keeps the view inside the world from (0, 0) to (width, height).
+// Zero bounds leave the camera unbounded.
+func (c *Camera) SetBounds(width, height int) {
+	c.boundsWidth, c.boundsHeight = float64(width), float64(height)
+	c.clampToBounds()
+}
+
+func (c *Camera) SetZoom(zoom float64) {
+	if zoom > 0 {
+		c.zoom = zoom
+		c.clampToBounds()
+	}
+}
+
+func (c *Camera) Zoom() float64 {
+	return c.zoom
+}
+
+// Bounds is the world rectangle the view stays in, empty when unbounded.
+func (c *Camera) Bounds() sdl.Rect {
+	return sdl.Rect{W: int32(c.boundsWidth), H: int32(c.boundsHeight)}
+}
+
+// DeadZone is the dead zone box on screen.
+func (c *Camera) DeadZone() sdl.Rect {
+	return sdl.Rect{
+		X: int32((float64(c.viewportWidth) - c.deadZoneWidth) / 2),
+		Y: int32((float64(c.viewportHeight) - c.deadZoneHeight) / 2),
+		W: int32(c.deadZoneWidth),
+		H: int32(c.deadZoneHeight),
+	}
+}
+
+// AddTrauma makes the camera shake; trauma is capped at 1 and wears off over
+// 1/CAMERA_TRAUMA_DECAY seconds.
+func (c *Camera) AddTrauma(amount float64) {
+	c.trauma = Clamp(c.trauma+amount, 0, 1)
+}
+
+func (c *Camera) Update(deltaTime float64) {
+	c.previous = c.center
+	if target, ok := c.targetCenter(); ok {
+		desired := c.center
+		halfWidth := c.deadZoneWidth / 2 / c.zoom
+		halfHeight := c.deadZoneHeight / 2 / c.zoom
+		if target.X() < c.center.X()-halfWidth {
+			desired[0] = target.X() + halfWidth
+		} else if target.X() > c.center.X()+halfWidth {
+			desired[0] = target.X() - halfWidth
+		}
+		if target.Y() < c.center.Y()-halfHeight {
+			desired[1] = target.Y() + halfHeight
+		} else if target.Y() > c.center.Y()+halfHeight {
+			desired[1] = target.Y() - halfHeight
+		}
+
+		c.center[0], c.velocity[0] = smoothDamp(c.center.X(), desired.X(), c.velocity.X(), c.smoothTime, deltaTime)
+		c.center[1], c.velocity[1] = smoothDamp(c.center.Y(), desired.Y(), c.velocity.Y(), c.smoothTime, deltaTime)
+	}
+	c.clampToBounds()
+
+	c.trauma = max(c.trauma-CAMERA_TRAUMA_DECAY*deltaTime, 0)
+	shake := c.trauma * c.trauma * CAMERA_MAX_SHAKE_OFFSET
+	c.shakeOffset = Vec2{shake * (rand.Float64()*2 - 1), shake * (rand.Float64()*2 - 1)}
+}
+
+func (c *Camera) targetCenter() (Vec2, bool) {
+	if c.target == nil || !c.target.HasComponent(TRANSFORM_COMPONENT) {
+		return Vec2{}, false
+	}
+	return c.target.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent).Center(), true
+}
+
+// smoothDamp moves current towards target as a critically damped spring,
+// stable for any deltaTime (Game Programming Gems 4, 1.10).
+func smoothDamp(current, target, velocity, smoothTime, deltaTime float64) (float64, float64) {
+	if smoothTime <= 0 || deltaTime <= 0 {
+		return target, 0
+	}
+	omega := 2 / smoothTime
+	x := omega * deltaTime
+	decay := 1 / (1 + x + 0.48*x*x + 0.235*x*x*x)
+	change := current - target
+	temp := (velocity + omega*change) * deltaTime
+	return target + (change+temp)*decay, (velocity - omega*temp) * decay
+}
+
+func (c *Camera) clampToBounds() {
+	if c.boundsWidth <= 0 || c.boundsHeight <= 0 {
+		return
+	}
+	c.center[0] = clampAxis(c.center.X(), float64(c.viewportWidth)/c.zoom/2, c.boundsWidth)
+	c.center[1] = clampAxis(c.center.Y(), float64(c.viewportHeight)/c.zoom/2, c.boundsHeight)
+}
+
+// clampAxis keeps a half-extent view inside [0, size], centering it when the
+// world is smaller than the view.
+func clampAxis(center, halfExtent, size float64) float64 {
+	if size < halfExtent*2 {
+		return size / 2
+	}
+	return Clamp(center, halfExtent, size-halfExtent)
+}
+
+// Center is the world point at the middle of the screen, without shake.
+func (c *Camera) Center() Vec2 {
+	return c.center
+}
+
+// Interpolate places the view alpha of the way from the previous fixed step's
+// center to the current one, to match interpolated sprites.
+func (c *Camera) Interpolate(alpha float64) {
+	c.alpha = Clamp(alpha, 0, 1)
+}
+
+func (c *Camera) viewCenter() Vec2 {
+	return Vec2{
+		c.previous.X() + (c.center.X()-c.previous.X())*c.alpha,
+		c.previous.Y() + (c.center.Y()-c.previous.Y())*c.alpha,
+	}
+}
+
+// View is the rectangle of the world visible on screen, shake included.
+func (c *Camera) View() sdl.Rect {
+	center := c.viewCenter()
+	width := float64(c.viewportWidth) / c.zoom
+	height := float64(c.viewportHeight) / c.zoom
+	return sdl.Rect{
+		X: int32(math.Floor(center.X() - width/2 + c.shakeOffset.X()/c.zoom)),
+		Y: int32(math.Floor(center.Y() - height/2 + c.shakeOffset.Y()/c.zoom)),
+		W: int32(math.Ceil(width)),
+		H: int32(math.Ceil(height)),
+	}
+}
+
+func (c *Camera) WorldToScreen(position Vec2) Vec2 {
+	center := c.viewCenter()
+	width := float64(c.viewportWidth) / c.zoom
+	height := float64(c.viewportHeight) / c.zoom
+	return Vec2{
+		(position.X()-(center.X()-width/2))*c.zoom - c.shakeOffset.X(),
+		(position.Y()-(center.Y()-height/2))*c.zoom - c.shakeOffset.Y(),
+	}
+}
+
+func (c *Camera) ScreenToWorld(position Vec2) Vec2 {
+	center := c.viewCenter()
+	width := float64(c.viewportWidth) / c.zoom
+	height := float64(c.viewportHeight) / c.zoom
+	return Vec2{
+		(position.X()+c.shakeOffset.X())/c.zoom + center.X() - width/2,
+		(position.Y()+c.shakeOffset.Y())/c.zoom + center.Y() - height/2,
+	}
+}
+
+// ToScreen maps a rectangle in world pixels to screen pixels. Edges are
+// rounded independently so neighbouring rectangles stay seamless when zoomed.
+func (c *Camera) ToScreen(rect sdl.Rect) sdl.Rect {
+	topLeft := c.WorldToScreen(Vec2{float64(rect.X), float64(rect.Y)})
+	bottomRight := c.WorldToScreen(Vec2{float64(rect.X + rect.W), float64(rect.Y + rect.H)})
+	x, y := int32(math.Round(topLeft.X())), int32(math.Round(topLeft.Y()))
+	return sdl.Rect{X: x, Y: y, W: int32(math.Round(bottomRight.X())) - x, H: int32(math.Round(bottomRight.Y())) - y}
+}
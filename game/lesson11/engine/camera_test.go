@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestCameraFollow(t *testing.T) {
+	manager := newTestManager()
+	target := manager.AddEntity("target", ENEMY_LAYER)
+	transform := target.AddComponent(NewTransformComponent(Vec2{90, 90}, Vec2{}, 20, 20, Vec2{1, 1}), TRANSFORM_COMPONENT).(*TransformComponent)
+
+	camera := NewCamera(200, 100)
+	camera.Follow(target)
+	camera.SetDeadZone(40, 40)
+	camera.SetSmoothTime(0)
+	camera.SetBounds(1000, 1000)
+	camera.SnapToTarget()
+	if camera.Center() != (Vec2{100, 100}) {
+		t.Fatalf("snapped to %v, want 100,100", camera.Center())
+	}
+
+	// Inside the dead zone the camera stays put
+	transform.position = Vec2{105, 80}
+	camera.Update(1.0 / 60)
+	if camera.Center() != (Vec2{100, 100}) {
+		t.Errorf("moved to %v for a target inside the dead zone", camera.Center())
+	}
+
+	// Leaving it, the camera moves just enough to bring it back to the edge
+	transform.position = Vec2{200, 100}
+	camera.Update(1.0 / 60)
+	if camera.Center() != (Vec2{190, 100}) {
+		t.Errorf("moved to %v, want 190,100", camera.Center())
+	}
+
+	// And it never shows past the world's edge
+	transform.position = Vec2{-500, 990}
+	camera.Update(1.0 / 60)
+	camera.Interpolate(1)
+	if view := camera.View(); view != (sdl.Rect{X: 0, Y: 900, W: 200, H: 100}) {
+		t.Errorf("view %v, want the bottom left corner of the world", view)
+	}
+}
+
+func TestCameraZoom(t *testing.T) {
+	camera := NewCamera(200, 100)
+	camera.SetZoom(2)
+	if view := camera.View(); view != (sdl.Rect{X: 50, Y: 25, W: 100, H: 50}) {
+		t.Errorf("zoomed view %v", view)
+	}
+	world := Vec2{60, 30}
+	screen := camera.WorldToScreen(world)
+	if screen != (Vec2{20, 10}) {
+		t.Errorf("%v is at %v on screen, want 20,10", world, screen)
+	}
+	if back := camera.ScreenToWorld(screen); math.Abs(back.X()-world.X()) > 1e-9 || math.Abs(back.Y()-world.Y()) > 1e-9 {
+		t.Errorf("%v maps back to %v", world, back)
+	}
+	if rect := camera.ToScreen(sdl.Rect{X: 60, Y: 30, W: 5, H: 5}); rect != (sdl.Rect{X: 20, Y: 10, W: 10, H: 10}) {
+		t.Errorf("rectangle on screen %v", rect)
+	}
+}
@@ -1,6 +1,111 @@
 package engine
 
-import "github.com/veandco/go-sdl2/sdl"
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const COLLISION_CELL_SIZE = 64
+
+type CollisionState int
+
+const (
+	COLLISION_ENTER CollisionState = iota
+	COLLISION_STAY
+	COLLISION_EXIT
+)
+
+type CollisionPair struct {
+	this          *Entity
+	that          *Entity
+	state         CollisionState
+	collisionType CollisionType
+}
+
+type collisionPairKey struct {
+	this EntityId
+	that EntityId
+}
+
+type cellKey struct {
+	x int32
+	y int32
+}
+
+// SpatialHash buckets collider rectangles into fixed-size grid cells so only
+// colliders sharing a cell are tested against each other.
+type SpatialHash struct {
+	cellSize int32
+	cells    map[cellKey][]*ColliderComponent
+	visited  map[collisionPairKey]bool
+}
+
+func NewSpatialHash(cellSize int) *SpatialHash {
+	return &SpatialHash{
+		cellSize: int32(cellSize),
+		cells:    make(map[cellKey][]*ColliderComponent),
+		visited:  make(map[collisionPairKey]bool),
+	}
+}
+
+func (h *SpatialHash) Clear() {
+	for key, bucket := range h.cells {
+		clear(bucket)
+		h.cells[key] = bucket[:0]
+	}
+}
+
+func (h *SpatialHash) Insert(collider *ColliderComponent) {
+	rect := collider.collider
+	minX, minY := h.cell(rect.X), h.cell(rect.Y)
+	maxX, maxY := h.cell(rect.X+rect.W), h.cell(rect.Y+rect.H)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			key := cellKey{x, y}
+			h.cells[key] = append(h.cells[key], collider)
+		}
+	}
+}
+
+func (h *SpatialHash) cell(coordinate int32) int32 {
+	if coordinate < 0 {
+		return (coordinate+1)/h.cellSize - 1
+	}
+	return coordinate / h.cellSize
+}
+
+// Pairs calls fn once for every pair of colliders whose rectangles overlap,
+// in no particular order.
+func (h *SpatialHash) Pairs(fn func(a, b *ColliderComponent)) {
+	visited := h.visited
+	clear(visited)
+	for _, bucket := range h.cells {
+		for i := 0; i < len(bucket); i++ {
+			for j := i + 1; j < len(bucket); j++ {
+				a, b := bucket[i], bucket[j]
+				if a.owner == b.owner {
+					continue
+				}
+				key := newCollisionPairKey(a.owner.id, b.owner.id)
+				if visited[key] {
+					continue
+				}
+				visited[key] = true
+				if CheckRectangleCollision(a.collider, b.collider) && (!a.oriented && !b.oriented || CheckPolygonCollision(a.Corners(), b.Corners())) {
+					fn(a, b)
+				}
+			}
+		}
+	}
+}
+
+func newCollisionPairKey(a, b EntityId) collisionPairKey {
+	if a > b {
+		a, b = b, a
+	}
+	return collisionPairKey{a, b}
+}
 
 func CheckRectangleCollision(rectangleA, rectangleB sdl.Rect) bool {
 	return (rectangleA.X+rectangleA.W >= rectangleB.X &&
@@ -8,3 +113,43 @@ func CheckRectangleCollision(rectangleA, rectangleB sdl.Rect) bool {
 		rectangleA.Y+rectangleA.H >= rectangleB.Y &&
 		rectangleB.Y+rectangleB.H >= rectangleA.Y)
 }
+
+// CheckPolygonCollision tests two convex quadrilaterals with the separating
+// axis theorem: they overlap unless the normal of an edge of one of them
+// separates them. Touching counts as overlapping, like
+// CheckRectangleCollision.
+func CheckPolygonCollision(a, b [4]Vec2) bool {
+	for _, polygon := range [][4]Vec2{a, b} {
+		for i, corner := range polygon {
+			edge := polygon[(i+1)%len(polygon)].Sub(corner)
+			axis := Vec2{-edge.Y(), edge.X()}
+			minA, maxA := project(a, axis)
+			minB, maxB := project(b, axis)
+			if maxA < minB || maxB < minA {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func project(polygon [4]Vec2, axis Vec2) (float64, float64) {
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, corner := range polygon {
+		distance := corner.Dot(axis)
+		lowest, highest = min(lowest, distance), max(highest, distance)
+	}
+	return lowest, highest
+}
+
+// boundingRect is the smallest rectangle around points.
+func boundingRect(points []Vec2) sdl.Rect {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX, minY = min(minX, p.X()), min(minY, p.Y())
+		maxX, maxY = max(maxX, p.X()), max(maxY, p.Y())
+	}
+	x, y := int32(math.Floor(minX)), int32(math.Floor(minY))
+	return sdl.Rect{X: x, Y: y, W: int32(math.Ceil(maxX)) - x, H: int32(math.Ceil(maxY)) - y}
+}
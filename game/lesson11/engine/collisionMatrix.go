@@ -0,0 +1,142 @@
+package engine
+
+import "fmt"
+
+type CollisionHandler func(pair CollisionPair)
+
+type collisionTagPair struct {
+	this string
+	that string
+}
+
+// CollisionMatrix decides which collider tags interact. Every tag owns a
+// category bit and a mask of the categories it collides with; rules then name
+// the CollisionType a tag pair produces, whatever order the pair arrives in.
+type CollisionMatrix struct {
+	categories map[string]uint64
+	masks      map[string]uint64
+	rules      map[collisionTagPair]CollisionType
+	typeNames  map[string]CollisionType
+	nextType   CollisionType
+	handlers   map[CollisionType][]CollisionHandler
+}
+
+func NewCollisionMatrix() *CollisionMatrix {
+	matrix := &CollisionMatrix{
+		categories: make(map[string]uint64),
+		masks:      make(map[string]uint64),
+		rules:      make(map[collisionTagPair]CollisionType),
+		typeNames: map[string]CollisionType{
+			"NO_COLLISION":                        NO_COLLISION,
+			"PLAYER_ENEMY_COLLISION":              PLAYER_ENEMY_COLLISION,
+			"PLAYER_PROJECTILE_COLLISION":         PLAYER_PROJECTILE_COLLISION,
+			"ENEMY_PROJECTILE_COLLISION":          ENEMY_PROJECTILE_COLLISION,
+			"PLAYER_VEGETATION_COLLIDER":          PLAYER_VEGETATION_COLLIDER,
+			"PLAYER_LEVEL_COMPLETE_COLLISION":     PLAYER_LEVEL_COMPLETE_COLLISION,
+			"ENEMY_FRIENDLY_PROJECTILE_COLLISION": ENEMY_FRIENDLY_PROJECTILE_COLLISION,
+		},
+		nextType: ENEMY_FRIENDLY_PROJECTILE_COLLISION + 1,
+		handlers: make(map[CollisionType][]CollisionHandler),
+	}
+	return matrix
+}
+
+// NewDefaultCollisionMatrix reproduces the engine's built-in tag rules, used
+// when a level does not declare its own collisions table.
+func NewDefaultCollisionMatrix() *CollisionMatrix {
+	matrix := NewCollisionMatrix()
+	matrix.AddRule("PLAYER", "ENEMY", PLAYER_ENEMY_COLLISION)
+	matrix.AddRule("PLAYER", "PROJECTILE", PLAYER_PROJECTILE_COLLISION)
+	matrix.AddRule("ENEMY", "PROJECTILE", ENEMY_PROJECTILE_COLLISION)
+	matrix.AddRule("PLAYER", "VEGETATION", PLAYER_VEGETATION_COLLIDER)
+	matrix.AddRule("PLAYER", "LEVEL_COMPLETE", PLAYER_LEVEL_COMPLETE_COLLISION)
+	matrix.AddRule("ENEMY", "FRIENDLY_PROJECTILE", ENEMY_FRIENDLY_PROJECTILE_COLLISION)
+	return matrix
+}
+
+// ClearRules drops categories, masks and rules but keeps registered types and
+// handlers, so a level can declare its own matrix.
+func (m *CollisionMatrix) ClearRules() {
+	clear(m.categories)
+	clear(m.masks)
+	clear(m.rules)
+}
+
+func (m *CollisionMatrix) AddCategory(tag string) (uint64, error) {
+	if category, ok := m.categories[tag]; ok {
+		return category, nil
+	}
+	if len(m.categories) >= 64 {
+		return 0, fmt.Errorf("too many collider categories, cannot add %s", tag)
+	}
+	category := uint64(1) << len(m.categories)
+	m.categories[tag] = category
+	return category, nil
+}
+
+// SetMask lets tag collide with each of the given tags. Masks are symmetric.
+func (m *CollisionMatrix) SetMask(tag string, collidesWith ...string) error {
+	category, err := m.AddCategory(tag)
+	if err != nil {
+		return err
+	}
+	for _, other := range collidesWith {
+		otherCategory, err := m.AddCategory(other)
+		if err != nil {
+			return err
+		}
+		m.masks[tag] |= otherCategory
+		m.masks[other] |= category
+	}
+	return nil
+}
+
+// AddRule makes this and that collide and report collisionType, with this as
+// the first entity of the pair.
+func (m *CollisionMatrix) AddRule(thisTag, thatTag string, collisionType CollisionType) error {
+	if err := m.SetMask(thisTag, thatTag); err != nil {
+		return err
+	}
+	m.rules[collisionTagPair{thisTag, thatTag}] = collisionType
+	return nil
+}
+
+// CollisionType returns the type registered under name, allocating a new one
+// for names the engine doesn't know yet.
+func (m *CollisionMatrix) CollisionType(name string) CollisionType {
+	if collisionType, ok := m.typeNames[name]; ok {
+		return collisionType
+	}
+	collisionType := m.nextType
+	m.nextType++
+	m.typeNames[name] = collisionType
+	return collisionType
+}
+
+func (m *CollisionMatrix) Collides(thisTag, thatTag string) bool {
+	return m.masks[thisTag]&m.categories[thatTag] != 0 || m.masks[thatTag]&m.categories[thisTag] != 0
+}
+
+// Classify matches a tag pair in either order; swapped is true when thatTag
+// holds the first tag of the matched rule.
+func (m *CollisionMatrix) Classify(thisTag, thatTag string) (collisionType CollisionType, swapped bool) {
+	if collisionType, ok := m.rules[collisionTagPair{thisTag, thatTag}]; ok {
+		return collisionType, false
+	}
+	if collisionType, ok := m.rules[collisionTagPair{thatTag, thisTag}]; ok {
+		return collisionType, true
+	}
+	return NO_COLLISION, false
+}
+
+func (m *CollisionMatrix) OnCollision(collisionType CollisionType, handler CollisionHandler) {
+	m.handlers[collisionType] = append(m.handlers[collisionType], handler)
+}
+
+func (m *CollisionMatrix) Dispatch(pairs []CollisionPair) {
+	for _, pair := range pairs {
+		for _, handler := range m.handlers[pair.collisionType] {
+			handler(pair)
+		}
+	}
+}
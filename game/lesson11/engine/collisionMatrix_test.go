@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestCollisionMatrix(t *testing.T) {
+	matrix := NewDefaultCollisionMatrix()
+	if err := matrix.SetMask("SENSOR", "ENEMY"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		this, that    string
+		collides      bool
+		collisionType CollisionType
+		swapped       bool
+	}{
+		{"PLAYER", "ENEMY", true, PLAYER_ENEMY_COLLISION, false},
+		{"ENEMY", "PLAYER", true, PLAYER_ENEMY_COLLISION, true},
+		{"ENEMY", "PROJECTILE", true, ENEMY_PROJECTILE_COLLISION, false},
+		{"PROJECTILE", "ENEMY", true, ENEMY_PROJECTILE_COLLISION, true},
+		{"LEVEL_COMPLETE", "PLAYER", true, PLAYER_LEVEL_COMPLETE_COLLISION, true},
+		{"FRIENDLY_PROJECTILE", "ENEMY", true, ENEMY_FRIENDLY_PROJECTILE_COLLISION, true},
+		{"FRIENDLY_PROJECTILE", "PLAYER", false, NO_COLLISION, false},
+		{"SENSOR", "ENEMY", true, NO_COLLISION, false},
+		{"ENEMY", "SENSOR", true, NO_COLLISION, false},
+		{"SENSOR", "PLAYER", false, NO_COLLISION, false},
+		{"PLAYER", "PLAYER", false, NO_COLLISION, false},
+		{"ENEMY", "ENEMY", false, NO_COLLISION, false},
+		{"PLAYER", "UNKNOWN", false, NO_COLLISION, false},
+	} {
+		if collides := matrix.Collides(test.this, test.that); collides != test.collides {
+			t.Errorf("%s collides with %s: %v, want %v", test.this, test.that, collides, test.collides)
+		}
+		collisionType, swapped := matrix.Classify(test.this, test.that)
+		if collisionType != test.collisionType || swapped != test.swapped {
+			t.Errorf("%s and %s classified as %v swapped %v, want %v swapped %v",
+				test.this, test.that, collisionType, swapped, test.collisionType, test.swapped)
+		}
+	}
+}
+
+func TestLoadCollisions(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	manager := newTestManager()
+	loader := &LevelLoader{manager: manager}
+	err := loader.loadCollisions(runLua(t, L, `{
+		rules = {
+			[0] = { this = "PLAYER", that = "VEGETATION", type = "PLAYER_BUSH_COLLISION" }
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collisions := manager.GetCollisionMatrix()
+	if collisions.Collides("PLAYER", "ENEMY") {
+		t.Error("the default rules survived loading a level's own")
+	}
+	var hits []CollisionPair
+	collisions.OnCollision(collisions.CollisionType("PLAYER_BUSH_COLLISION"), func(pair CollisionPair) {
+		hits = append(hits, pair)
+	})
+
+	for _, tag := range []string{"VEGETATION", "PLAYER", "ENEMY"} {
+		entity := manager.AddEntity(tag, ENEMY_LAYER)
+		entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 10, 10, Vec2{1, 1}), TRANSFORM_COMPONENT)
+		entity.AddComponent(NewColliderComponent(tag, 0, 0, 0, 0), COLLIDER_COMPONENT)
+	}
+	manager.Update(0)
+	manager.CheckCollisions()
+	if len(hits) != 1 || hits[0].this.name != "PLAYER" || hits[0].that.name != "VEGETATION" || hits[0].state != COLLISION_ENTER {
+		t.Errorf("handler got %v, want the player entering the vegetation", hits)
+	}
+}
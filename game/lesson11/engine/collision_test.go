@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestCheckCollisions(t *testing.T) {
+	manager := newTestManager()
+	transforms := map[string]*TransformComponent{}
+	for _, tag := range []string{"PLAYER", "ENEMY", "PROJECTILE"} {
+		entity := manager.AddEntity(tag, ENEMY_LAYER)
+		transforms[tag] = entity.AddComponent(NewTransformComponent(Vec2{1000, 1000}, Vec2{}, 10, 10, Vec2{1, 1}), TRANSFORM_COMPONENT).(*TransformComponent)
+		entity.AddComponent(NewColliderComponent(tag, 0, 0, 0, 0), COLLIDER_COMPONENT)
+	}
+	transforms["PLAYER"].position = Vec2{0, 0}
+	transforms["ENEMY"].position = Vec2{5, 0}
+
+	frame := func(want ...string) {
+		t.Helper()
+		manager.Update(0)
+		got := []string{}
+		for _, pair := range manager.CheckCollisions() {
+			got = append(got, fmt.Sprintf("%s-%s %d", pair.this.name, pair.that.name, pair.state))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("collisions %v, want %v", got, want)
+		}
+	}
+
+	frame("PLAYER-ENEMY 0")
+
+	transforms["PROJECTILE"].position = Vec2{0, 5}
+	frame("PLAYER-ENEMY 1", "PLAYER-PROJECTILE 0", "ENEMY-PROJECTILE 0")
+
+	transforms["ENEMY"].position = Vec2{200, 200}
+	frame("PLAYER-PROJECTILE 1", "PLAYER-ENEMY 2", "ENEMY-PROJECTILE 2")
+
+	frame("PLAYER-PROJECTILE 1")
+
+	transforms["PROJECTILE"].position = Vec2{500, 500}
+	frame("PLAYER-PROJECTILE 2")
+	frame()
+}
+
+func TestCheckPolygonCollision(t *testing.T) {
+	square := [4]Vec2{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	diamond := [4]Vec2{{0, -5}, {5, 0}, {0, 5}, {-5, 0}}
+	shift := func(polygon [4]Vec2, by Vec2) [4]Vec2 {
+		for i := range polygon {
+			polygon[i] = polygon[i].Add(by)
+		}
+		return polygon
+	}
+
+	for _, test := range []struct {
+		name string
+		a, b [4]Vec2
+		want bool
+	}{
+		{"overlapping squares", square, shift(square, Vec2{5, 5}), true},
+		{"squares touching edges", square, shift(square, Vec2{10, 0}), true},
+		{"squares apart", square, shift(square, Vec2{11, 0}), false},
+		{"diamonds sharing an edge", diamond, shift(diamond, Vec2{5, 5}), true},
+		{"diamonds only overlapping as boxes", diamond, shift(diamond, Vec2{6, 6}), false},
+		{"diamond in a square's corner", square, shift(diamond, Vec2{-4, -4}), false},
+		{"diamond over a square's corner", square, shift(diamond, Vec2{-2, -2}), true},
+	} {
+		if got := CheckPolygonCollision(test.a, test.b); got != test.want {
+			t.Errorf("%s: %v, want %v", test.name, got, test.want)
+		}
+		if got := CheckPolygonCollision(test.b, test.a); got != test.want {
+			t.Errorf("%s swapped: %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -12,99 +13,330 @@ const (
 	TRANSFORM_COMPONENT ComponentType = iota
 	SPRITE_COMPONENT
 	KEYBOARD_CONTROL_COMPONENT
-	TILE_COMPONENT
+	TILEMAP_COMPONENT
 	COLLIDER_COMPONENT
 	TEXT_LABEL_COMPONENT
 	PROJECTILE_EMITTER_COMPONENT
+	SOUND_EMITTER_COMPONENT
+	PROJECTILE_COMPONENT
+	WEAPON_COMPONENT
+	HEALTH_COMPONENT
+	DAMAGE_COMPONENT
+	AI_COMPONENT
+	PARTICLE_EMITTER_COMPONENT
+	MINIMAP_COMPONENT
+	NUM_COMPONENT_TYPES
 )
 
+var componentTypeNames = [NUM_COMPONENT_TYPES]string{
+	"Transform", "Sprite", "KeyboardControl", "Tilemap", "Collider", "TextLabel", "ProjectileEmitter",
+	"SoundEmitter", "Projectile", "Weapon", "Health", "Damage", "AI", "ParticleEmitter", "Minimap",
+}
+
+func (t ComponentType) String() string {
+	if t >= 0 && t < NUM_COMPONENT_TYPES {
+		return componentTypeNames[t]
+	}
+	return fmt.Sprintf("ComponentType(%d)", int(t))
+}
+
 type Component interface {
 	SetOwner(*Entity)
 	Initialize()
 	Update(float64)
-	Render(*sdl.Renderer)
-}
-
+	Render(Renderer)
+}
+
+// TransformComponent keeps the position of the previous fixed step next to the
+// current one, so rendering can interpolate between the two.
+//
+// position is the top left corner of the unrotated box, width by height
+// stretched by scale. The box turns rotation degrees clockwise around its
+// pivot, given as a fraction of its size, the center by default. flip mirrors
+// what is drawn without moving the box. A transform that faces its velocity
+// turns so that facing, the direction its sprite is drawn in, points the way
+// it moves.
+//
+// position, rotation and scale are always in the world. The transform of a
+// child entity is given by the local fields instead: its center's offset from
+// the parent's center, in the parent's rotated and scaled space, and a
+// rotation and scale on top of the parent's. Its velocity moves the offset,
+// and the world fields are worked out after every update. Pivot and flip
+// aren't passed down. Attaching a transform to a parent keeps it where it is
+// in the world, except when it is added to an entity that already has one, as
+// the level loader does: then its fields are read as the local ones.
 type TransformComponent struct {
-	owner    *Entity
-	position Vec2
-	velocity Vec2
-	width    int
-	height   int
-	scale    int
-}
-
-func NewTransformComponent(position, velocity Vec2, width, height, scale int) *TransformComponent {
-	return &TransformComponent{position: position, velocity: velocity, width: width, height: height, scale: scale}
+	owner         *Entity
+	position      Vec2
+	previous      Vec2
+	velocity      Vec2
+	width         int
+	height        int
+	scale         Vec2
+	rotation      float64
+	pivot         Vec2
+	flip          sdl.RendererFlip
+	faceVelocity  bool
+	facing        float64
+	parent        *TransformComponent
+	localPosition Vec2
+	localRotation float64
+	localScale    Vec2
+	resolved      uint64
+}
+
+func NewTransformComponent(position, velocity Vec2, width, height int, scale Vec2) *TransformComponent {
+	return &TransformComponent{position: position, previous: position, velocity: velocity, width: width, height: height,
+		scale: scale, pivot: Vec2{0.5, 0.5}}
 }
 
 func (c *TransformComponent) SetOwner(e *Entity) {
 	c.owner = e
 }
 
-func (c *TransformComponent) Initialize() {}
+// Initialize links the transform to its parent's when the entity was given a
+// parent before its transform, taking its position as its center's offset
+// from the parent's and its rotation and scale as local ones.
+func (c *TransformComponent) Initialize() {
+	if parent := c.owner.parent; parent != nil && parent.HasComponent(TRANSFORM_COMPONENT) {
+		c.parent = parent.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+		c.localPosition, c.localRotation, c.localScale = c.position, c.rotation, c.scale
+		c.combine()
+		c.previous = c.position
+	}
+}
 
 func (c *TransformComponent) Update(deltaTime float64) {
+	c.previous = c.position
+	if c.faceVelocity && (c.velocity.X() != 0 || c.velocity.Y() != 0) {
+		// A child's velocity is in its parent's space, like its rotation
+		c.SetRotation(Degrees(math.Atan2(c.velocity.Y(), c.velocity.X())) - c.facing)
+	}
+	if c.parent != nil {
+		c.localPosition[0] += c.velocity[0] * deltaTime
+		c.localPosition[1] += c.velocity[1] * deltaTime
+		return
+	}
 	c.position[0] += c.velocity[0] * deltaTime
 	c.position[1] += c.velocity[1] * deltaTime
 }
 
-func (c *TransformComponent) Render(renderer *sdl.Renderer) {}
+// SetRotation turns the transform to angle degrees, relative to its parent's
+// rotation for a child.
+func (c *TransformComponent) SetRotation(angle float64) {
+	if c.parent != nil {
+		c.localRotation = angle
+		return
+	}
+	c.rotation = angle
+}
+
+// SetScale stretches the transform, relative to its parent's scale for a
+// child.
+func (c *TransformComponent) SetScale(scale Vec2) {
+	if c.parent != nil {
+		c.localScale = scale
+		return
+	}
+	// A root transform keeps its center where it was
+	center := c.Center()
+	c.scale = scale
+	c.position = c.fromCenter(center)
+}
+
+// SetPivot moves the point the transform turns around, as a fraction of its
+// size, keeping what is drawn where it was.
+func (c *TransformComponent) SetPivot(pivot Vec2) {
+	center := c.Center()
+	c.pivot = pivot
+	c.position = c.fromCenter(center)
+}
+
+func (c *TransformComponent) SetFlip(flip sdl.RendererFlip) {
+	c.flip = flip
+}
+
+// FaceVelocity turns the transform the way it moves from now on, its sprite
+// being drawn facing facing degrees.
+func (c *TransformComponent) FaceVelocity(facing float64) {
+	c.faceVelocity = true
+	c.facing = facing
+}
+
+func (c *TransformComponent) Rotation() float64 {
+	return c.rotation
+}
+
+func (c *TransformComponent) Scale() Vec2 {
+	return c.scale
+}
+
+func (c *TransformComponent) Flip() sdl.RendererFlip {
+	return c.flip
+}
+
+// setParent attaches the transform to parent where it is in the world,
+// working out its local fields from its world ones, or with nil detaches it
+// where it is.
+func (c *TransformComponent) setParent(parent *TransformComponent) {
+	c.parent = parent
+	if parent == nil {
+		return
+	}
+	c.localRotation = c.rotation - parent.rotation
+	if offset, ok := parent.localOffset(c.Center()); ok {
+		c.localPosition = offset
+		c.localScale = Vec2{c.scale.X() / parent.scale.X(), c.scale.Y() / parent.scale.Y()}
+	} else {
+		c.localPosition, c.localScale = Vec2{}, c.scale
+	}
+	c.combine()
+}
+
+// localOffset is point's offset from the center in the transform's rotated
+// and scaled space, where a child centered on point sits; false when the
+// transform is scaled to nothing.
+func (c *TransformComponent) localOffset(point Vec2) (Vec2, bool) {
+	if c.scale.X() == 0 || c.scale.Y() == 0 {
+		return Vec2{}, false
+	}
+	offset := rotate(point.Sub(c.Center()), -c.rotation)
+	return Vec2{offset.X() / c.scale.X(), offset.Y() / c.scale.Y()}, true
+}
+
+// resolve works out a child's world transform from its parent's, the
+// parent's first. Each transform is resolved once per step.
+func (c *TransformComponent) resolve(step uint64) {
+	if c.parent == nil || c.resolved == step {
+		return
+	}
+	c.resolved = step
+	c.parent.resolve(step)
+	c.combine()
+}
+
+func (c *TransformComponent) combine() {
+	parentScale := c.parent.scale
+	c.scale = Vec2{parentScale.X() * c.localScale.X(), parentScale.Y() * c.localScale.Y()}
+	c.rotation = c.parent.rotation + c.localRotation
+	offset := rotate(Vec2{c.localPosition.X() * parentScale.X(), c.localPosition.Y() * parentScale.Y()}, c.parent.rotation)
+	c.position = c.fromCenter(c.parent.Center().Add(offset))
+}
+
+// Teleport moves to position without interpolating the jump. A child keeps
+// following its parent from the new place.
+func (c *TransformComponent) Teleport(position Vec2) {
+	c.position = position
+	c.previous = position
+	if c.parent == nil {
+		return
+	}
+	if offset, ok := c.parent.localOffset(c.Center()); ok {
+		c.localPosition = offset
+	}
+}
+
+// Parent is the transform this one follows, if any.
+func (c *TransformComponent) Parent() *TransformComponent {
+	return c.parent
+}
+
+// Interpolated is the position alpha of the way from the previous fixed step
+// to the current one.
+func (c *TransformComponent) Interpolated(alpha float64) Vec2 {
+	return Vec2{
+		c.previous.X() + (c.position.X()-c.previous.X())*alpha,
+		c.previous.Y() + (c.position.Y()-c.previous.Y())*alpha,
+	}
+}
+
+// Size is the width and height stretched by the scale.
+func (c *TransformComponent) Size() Vec2 {
+	return Vec2{float64(c.width) * c.scale.X(), float64(c.height) * c.scale.Y()}
+}
+
+// Pivot is the point the transform turns around, relative to its top left
+// corner.
+func (c *TransformComponent) Pivot() Vec2 {
+	size := c.Size()
+	return Vec2{size.X() * c.pivot.X(), size.Y() * c.pivot.Y()}
+}
+
+// Center is the middle of the scaled and rotated box.
+func (c *TransformComponent) Center() Vec2 {
+	size := c.Size()
+	pivot := c.Pivot()
+	return c.position.Add(pivot).Add(rotate(Vec2{size.X()/2 - pivot.X(), size.Y()/2 - pivot.Y()}, c.rotation))
+}
+
+// fromCenter is the position that puts the box's center at center.
+func (c *TransformComponent) fromCenter(center Vec2) Vec2 {
+	size := c.Size()
+	pivot := c.Pivot()
+	return center.Sub(rotate(Vec2{size.X()/2 - pivot.X(), size.Y()/2 - pivot.Y()}, c.rotation)).Sub(pivot)
+}
+
+// Corners are the corners of the scaled and rotated box, clockwise from the
+// top left one before rotating.
+func (c *TransformComponent) Corners() [4]Vec2 {
+	size := c.Size()
+	pivot := c.position.Add(c.Pivot())
+	var corners [4]Vec2
+	for i, corner := range [4]Vec2{{0, 0}, {size.X(), 0}, {size.X(), size.Y()}, {0, size.Y()}} {
+		corners[i] = pivot.Add(rotate(c.position.Add(corner).Sub(pivot), c.rotation))
+	}
+	return corners
+}
+
+func (c *TransformComponent) Render(renderer Renderer) {}
 
 type SpriteComponent struct {
 	owner                *Entity
 	transform            *TransformComponent
-	texture              *sdl.Texture
+	texture              Texture
 	sourceRectangle      sdl.Rect
 	destinationRectangle sdl.Rect
-	isAnimated           bool
-	numFrames            int
-	animationSpeed       int
 	isFixed              bool
-	animations           map[string]Animation
-	currentAnimationName string
-	animationIndex       uint
+	animations           *AnimationSet
+	animator             *Animator
 	spriteFilp           sdl.RendererFlip
+	gridFrames           int
+	gridFrameDuration    float64
+	gridRows             []string
 }
 
-func NewSpriteComponent(texture *sdl.Texture) *SpriteComponent {
-	return &SpriteComponent{texture: texture, animations: make(map[string]Animation)}
+func NewSpriteComponent(texture Texture) *SpriteComponent {
+	return &SpriteComponent{texture: texture}
 }
 
-func NewSpriteComponent2(texture *sdl.Texture, numFrames, animationSpeed int, hasDirections, isFixed bool) *SpriteComponent {
-	sprite := &SpriteComponent{texture: texture, animations: make(map[string]Animation), isAnimated: true, numFrames: numFrames, animationSpeed: animationSpeed, isFixed: isFixed}
+// NewSpriteComponent2 animates a sheet of numFrames columns showing each
+// frame for animationSpeed milliseconds, with either one row or the four
+// direction rows. The frames are cut in Initialize, once the transform gives
+// their size.
+func NewSpriteComponent2(texture Texture, numFrames, animationSpeed int, hasDirections, isFixed bool) *SpriteComponent {
+	sprite := &SpriteComponent{texture: texture, isFixed: isFixed, gridFrames: numFrames, gridFrameDuration: float64(animationSpeed) / 1000}
 
 	if hasDirections {
-		downAnimation := Animation{0, numFrames, animationSpeed}
-		rightAnimation := Animation{1, numFrames, animationSpeed}
-		leftAnimation := Animation{2, numFrames, animationSpeed}
-		upAnimation := Animation{3, numFrames, animationSpeed}
-
-		sprite.animations["DownAnimation"] = downAnimation
-		sprite.animations["RightAnimation"] = rightAnimation
-		sprite.animations["LeftAnimation"] = leftAnimation
-		sprite.animations["UpAnimation"] = upAnimation
-
-		sprite.animationIndex = 0
-		sprite.currentAnimationName = "DownAnimation"
+		sprite.gridRows = []string{"DownAnimation", "RightAnimation", "LeftAnimation", "UpAnimation"}
 	} else {
-		singleAnimation := Animation{0, numFrames, animationSpeed}
-		sprite.animations["SingleAnimation"] = singleAnimation
-		sprite.animationIndex = 0
-		sprite.currentAnimationName = "SingleAnimation"
+		sprite.gridRows = []string{"SingleAnimation"}
 	}
 
-	sprite.Play(sprite.currentAnimationName)
-
 	return sprite
 }
 
+func NewAnimatedSpriteComponent(texture Texture, animations *AnimationSet, isFixed bool) *SpriteComponent {
+	return &SpriteComponent{texture: texture, animations: animations, isFixed: isFixed}
+}
+
 func (c *SpriteComponent) Play(animationName string) {
-	animation := c.animations[animationName]
-	c.numFrames = animation.numFrames
-	c.animationIndex = animation.index
-	c.animationSpeed = animation.animationSpeed
-	c.currentAnimationName = animationName
+	if c.animator != nil {
+		c.animator.Play(animationName)
+	}
+}
+
+func (c *SpriteComponent) Animator() *Animator {
+	return c.animator
 }
 
 func (c *SpriteComponent) SetOwner(e *Entity) {
@@ -117,30 +349,53 @@ func (c *SpriteComponent) Initialize() {
 	c.sourceRectangle.Y = 0
 	c.sourceRectangle.W = int32(c.transform.width)
 	c.sourceRectangle.H = int32(c.transform.height)
+
+	if c.gridFrames > 0 {
+		c.animations = NewGridAnimationSet(c.transform.width, c.transform.height, c.gridFrames, c.gridFrameDuration, c.gridRows...)
+	}
+	if c.animations != nil {
+		c.animator = NewAnimator(c.animations)
+	}
 }
 
 func (c *SpriteComponent) Update(deltaTime float64) {
-	camera := c.owner.manager.camera
-
-	if c.isAnimated {
-		c.sourceRectangle.X = c.sourceRectangle.W * int32(int(float64(sdl.GetTicks64())/float64(c.animationSpeed))%c.numFrames)
+	if c.animator != nil {
+		c.animator.Update(deltaTime)
+		if source, ok := c.animator.Source(); ok {
+			c.sourceRectangle = source
+		}
 	}
-	c.sourceRectangle.Y = int32(c.animationIndex) * int32(c.transform.height)
+}
 
-	c.destinationRectangle.X = int32(c.transform.position.X())
-	c.destinationRectangle.Y = int32(c.transform.position.Y())
+// Render places the sprite where its transform is between fixed steps,
+// turned and mirrored like the transform.
+func (c *SpriteComponent) Render(renderer Renderer) {
+	position := c.transform.Interpolated(c.owner.manager.alpha)
+	size := c.transform.Size()
+	c.destinationRectangle.X = int32(position.X())
+	c.destinationRectangle.Y = int32(position.Y())
+	c.destinationRectangle.W = int32(math.Round(size.X()))
+	c.destinationRectangle.H = int32(math.Round(size.Y()))
 	if !c.isFixed {
-		c.destinationRectangle.X -= camera.X
-		c.destinationRectangle.Y -= camera.Y
+		c.destinationRectangle = c.owner.manager.camera.ToScreen(c.destinationRectangle)
 	}
-	c.destinationRectangle.W = int32(c.transform.width * c.transform.scale)
-	c.destinationRectangle.H = int32(c.transform.height * c.transform.scale)
-}
 
-func (c *SpriteComponent) Render(renderer *sdl.Renderer) {
-	DrawTexture(c.texture, c.sourceRectangle, c.destinationRectangle, c.spriteFilp, renderer)
+	// The pivot is a fraction of the size, so it follows the camera's zoom
+	pivot := sdl.Point{
+		X: int32(math.Round(c.transform.pivot.X() * float64(c.destinationRectangle.W))),
+		Y: int32(math.Round(c.transform.pivot.Y() * float64(c.destinationRectangle.H))),
+	}
+	renderer.DrawSprite(c.texture, c.sourceRectangle, c.destinationRectangle, c.transform.rotation, &pivot, c.spriteFilp^c.transform.flip)
 }
 
+const (
+	MOVE_UP_ACTION    = "move_up"
+	MOVE_RIGHT_ACTION = "move_right"
+	MOVE_DOWN_ACTION  = "move_down"
+	MOVE_LEFT_ACTION  = "move_left"
+	SHOOT_ACTION      = "shoot"
+)
+
 type KeyboardControlComponent struct {
 	owner     *Entity
 	upKey     string
@@ -150,6 +405,9 @@ type KeyboardControlComponent struct {
 	shootKey  string
 	transform *TransformComponent
 	sprite    *SpriteComponent
+	weapon    *WeaponComponent
+	bindings  map[string][]inputBinding
+	err       error
 }
 
 func NewKeyboardControlComponent(upKey, rightKey, downKey, leftKey, shootKey string) *KeyboardControlComponent {
@@ -162,94 +420,111 @@ func (c *KeyboardControlComponent) SetOwner(e *Entity) {
 
 func (c *KeyboardControlComponent) Initialize() {
 	c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
-	c.sprite = c.owner.GetComponent(SPRITE_COMPONENT).(*SpriteComponent)
-}
+	if c.owner.HasComponent(SPRITE_COMPONENT) {
+		c.sprite = c.owner.GetComponent(SPRITE_COMPONENT).(*SpriteComponent)
+	}
+	if c.owner.HasComponent(WEAPON_COMPONENT) {
+		c.weapon = c.owner.GetComponent(WEAPON_COMPONENT).(*WeaponComponent)
+	}
 
-func (c *KeyboardControlComponent) Update(deltaTime float64) {
-	event := *c.owner.manager.event
-	switch t := event.(type) {
-	case *sdl.KeyboardEvent:
-		key := sdl.GetKeyName(t.Keysym.Sym)
-		switch t.Type {
-		case sdl.KEYDOWN:
-			switch key {
-			case c.upKey:
-				c.transform.velocity[1] = -25
-				c.transform.velocity[0] = 0
-				c.sprite.Play("UpAnimation")
-			case c.rightKey:
-				c.transform.velocity[1] = 0
-				c.transform.velocity[0] = 25
-				c.sprite.Play("RightAnimation")
-			case c.downKey:
-				c.transform.velocity[1] = 25
-				c.transform.velocity[0] = 0
-				c.sprite.Play("DownAnimation")
-			case c.leftKey:
-				c.transform.velocity[1] = 0
-				c.transform.velocity[0] = -25
-				c.sprite.Play("LeftAnimation")
-			}
-		case sdl.KEYUP:
-			switch key {
-			case c.upKey:
-				c.transform.velocity[1] = 0
-			case c.rightKey:
-				c.transform.velocity[0] = 0
-			case c.downKey:
-				c.transform.velocity[1] = 0
-			case c.leftKey:
-				c.transform.velocity[0] = 0
-			}
+	// Keys given to the component work on top of the configured bindings, for
+	// this entity only, so they don't pile up in the game wide Input
+	c.bindings = make(map[string][]inputBinding)
+	c.err = nil
+	for action, key := range map[string]string{
+		MOVE_UP_ACTION:    c.upKey,
+		MOVE_RIGHT_ACTION: c.rightKey,
+		MOVE_DOWN_ACTION:  c.downKey,
+		MOVE_LEFT_ACTION:  c.leftKey,
+		SHOOT_ACTION:      c.shootKey,
+	} {
+		if key == "" {
+			continue
+		}
+		binding, err := parseInputBinding(key)
+		if err != nil {
+			c.err = fmt.Errorf("failed to bind %s: %v", action, err)
+			continue
 		}
+		c.bindings[action] = append(c.bindings[action], binding)
 	}
 }
 
-func (c *KeyboardControlComponent) Render(renderer *sdl.Renderer) {}
-
-type TileComponent struct {
-	owner                *Entity
-	texture              *sdl.Texture
-	sourceRectangle      sdl.Rect
-	destinationRectangle sdl.Rect
-	position             Vec2
+// Err reports a key that Initialize couldn't bind.
+func (c *KeyboardControlComponent) Err() error {
+	return c.err
 }
 
-func NewTileComponent(sourceRectX, sourceRectY, x, y, tileSize, tileScale int, assetTexture *sdl.Texture) *TileComponent {
-	tile := &TileComponent{texture: assetTexture}
+// state merges the configured bindings of action with the component's own.
+func (c *KeyboardControlComponent) state(input *Input, action string) buttonState {
+	state := buttonState{down: input.ActionDown(action), pressed: input.ActionPressed(action), released: input.ActionReleased(action)}
+	for _, binding := range c.bindings[action] {
+		own := input.bindingState(binding)
+		state.down = state.down || own.down
+		state.pressed = state.pressed || own.pressed
+		state.released = state.released || own.released
+	}
+	return state
+}
 
-	tile.sourceRectangle.X = int32(sourceRectX)
-	tile.sourceRectangle.Y = int32(sourceRectY)
-	tile.sourceRectangle.W = int32(tileSize)
-	tile.sourceRectangle.H = int32(tileSize)
+func (c *KeyboardControlComponent) play(animationName string) {
+	if c.sprite != nil {
+		c.sprite.Play(animationName)
+	}
+}
 
-	tile.destinationRectangle.X = int32(x)
-	tile.destinationRectangle.Y = int32(y)
-	tile.destinationRectangle.W = int32(tileSize * tileScale)
-	tile.destinationRectangle.H = int32(tileSize * tileScale)
+func (c *KeyboardControlComponent) Update(deltaTime float64) {
+	input := c.owner.manager.input
 
-	tile.position[0] = float64(x)
-	tile.position[1] = float64(y)
+	if c.state(input, MOVE_UP_ACTION).pressed {
+		c.transform.velocity[1] = -25
+		c.transform.velocity[0] = 0
+		c.play("UpAnimation")
+		c.aim(Vec2{0, -1})
+	}
+	if c.state(input, MOVE_RIGHT_ACTION).pressed {
+		c.transform.velocity[1] = 0
+		c.transform.velocity[0] = 25
+		c.play("RightAnimation")
+		c.aim(Vec2{1, 0})
+	}
+	if c.state(input, MOVE_DOWN_ACTION).pressed {
+		c.transform.velocity[1] = 25
+		c.transform.velocity[0] = 0
+		c.play("DownAnimation")
+		c.aim(Vec2{0, 1})
+	}
+	if c.state(input, MOVE_LEFT_ACTION).pressed {
+		c.transform.velocity[1] = 0
+		c.transform.velocity[0] = -25
+		c.play("LeftAnimation")
+		c.aim(Vec2{-1, 0})
+	}
 
-	return tile
-}
+	if c.state(input, MOVE_UP_ACTION).released || c.state(input, MOVE_DOWN_ACTION).released {
+		c.transform.velocity[1] = 0
+	}
+	if c.state(input, MOVE_RIGHT_ACTION).released || c.state(input, MOVE_LEFT_ACTION).released {
+		c.transform.velocity[0] = 0
+	}
 
-func (c *TileComponent) SetOwner(e *Entity) {
-	c.owner = e
+	// Holding shoot keeps firing at the weapon's fire rate
+	if c.weapon != nil && c.state(input, SHOOT_ACTION).down {
+		c.weapon.Fire()
+	}
 }
 
-func (c *TileComponent) Initialize() {}
-
-func (c *TileComponent) Update(deltaTime float64) {
-	camera := c.owner.manager.camera
-	c.destinationRectangle.X = int32(c.position.X() - float64(camera.X))
-	c.destinationRectangle.Y = int32(c.position.Y() - float64(camera.Y))
+func (c *KeyboardControlComponent) aim(direction Vec2) {
+	if c.weapon != nil {
+		c.weapon.Aim(direction)
+	}
 }
 
-func (c *TileComponent) Render(renderer *sdl.Renderer) {
-	DrawTexture(c.texture, c.sourceRectangle, c.destinationRectangle, sdl.FLIP_NONE, renderer)
-}
+func (c *KeyboardControlComponent) Render(renderer Renderer) {}
 
+// ColliderComponent covers its transform's box. An oriented collider turns
+// with the transform; its collider rectangle is then only the bounds of the
+// turned box, and overlapping bounds are checked again against the corners.
 type ColliderComponent struct {
 	owner                *Entity
 	colliderTag          string
@@ -257,6 +532,8 @@ type ColliderComponent struct {
 	sourceRectangle      sdl.Rect
 	destinationRectangle sdl.Rect
 	transform            *TransformComponent
+	oriented             bool
+	corners              [4]Vec2
 }
 
 func NewColliderComponent(colliderTag string, x, y, width, height int) *ColliderComponent {
@@ -277,65 +554,70 @@ func (c *ColliderComponent) Initialize() {
 	}
 }
 
-func (c *ColliderComponent) Update(deltaTime float64) {
-	camera := c.owner.manager.camera
+// SetOriented makes the collider turn with its transform, or stay upright.
+func (c *ColliderComponent) SetOriented(oriented bool) {
+	c.oriented = oriented
+}
 
+func (c *ColliderComponent) Update(deltaTime float64) {
+	if c.oriented {
+		c.corners = c.transform.Corners()
+		c.collider = boundingRect(c.corners[:])
+		return
+	}
+	size := c.transform.Size()
 	c.collider.X = int32(c.transform.position.X())
 	c.collider.Y = int32(c.transform.position.Y())
-	c.collider.W = int32(c.transform.width * c.transform.scale)
-	c.collider.H = int32(c.transform.height * c.transform.scale)
-
-	c.destinationRectangle.X = c.collider.X - camera.X
-	c.destinationRectangle.Y = c.collider.Y - camera.Y
+	c.collider.W = int32(math.Round(size.X()))
+	c.collider.H = int32(math.Round(size.Y()))
 }
 
-func (c *ColliderComponent) Render(renderer *sdl.Renderer) {}
-
-type TextLabelComponent struct {
-	owner      *Entity
-	position   sdl.Rect
-	text       string
-	fontFamily string
-	color      sdl.Color
-	texture    *sdl.Texture
-}
-
-func NewTextLabelComponent(x, y int, text, fontFamily string, color sdl.Color) *TextLabelComponent {
-	textLabel := &TextLabelComponent{text: text, fontFamily: fontFamily, color: color}
-	textLabel.position.X = int32(x)
-	textLabel.position.Y = int32(y)
-
-	return textLabel
+// Corners are the collider's corners clockwise, those of its rectangle when it
+// isn't oriented.
+func (c *ColliderComponent) Corners() [4]Vec2 {
+	if c.oriented {
+		return c.corners
+	}
+	x, y := float64(c.collider.X), float64(c.collider.Y)
+	w, h := float64(c.collider.W), float64(c.collider.H)
+	return [4]Vec2{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}
 }
 
-func (c *TextLabelComponent) SetOwner(e *Entity) {
-	c.owner = e
-}
+// Render outlines the collider while the debug overlay shows colliders.
+func (c *ColliderComponent) Render(renderer Renderer) {
+	manager := c.owner.manager
+	if manager.debug == nil || !manager.debug.enabled || manager.camera == nil {
+		return
+	}
 
-func (c *TextLabelComponent) Initialize() {
-	surface, err := c.owner.manager.assetManager.GetFont(c.fontFamily).RenderUTF8Blended(c.text, c.color)
-	if err != nil {
-		panic(err)
+	if c.oriented {
+		moved := c.transform.Interpolated(manager.alpha).Sub(c.transform.position)
+		for i, corner := range c.corners {
+			next := c.corners[(i+1)%len(c.corners)]
+			drawLine(renderer, manager.camera.WorldToScreen(corner.Add(moved)), manager.camera.WorldToScreen(next.Add(moved)), DEBUG_COLLIDER_COLOR)
+		}
+		c.destinationRectangle = manager.camera.ToScreen(c.collider)
+		return
 	}
-	defer surface.Free()
 
-	texture, err := c.owner.manager.renderer.CreateTextureFromSurface(surface)
-	if err != nil {
-		panic(err)
+	box := c.collider
+	if c.transform != nil {
+		position := c.transform.Interpolated(manager.alpha)
+		box.X, box.Y = int32(position.X()), int32(position.Y())
 	}
-	c.texture = texture
-	_, _, width, height, err := c.texture.Query()
-	if err != nil {
-		panic(err)
+	c.destinationRectangle = manager.camera.ToScreen(box)
+	renderer.DrawRect(c.destinationRectangle, DEBUG_COLLIDER_COLOR)
+}
+
+// drawLine plots a one pixel line, for outlines DrawRect can't draw.
+func drawLine(renderer Renderer, from, to Vec2, color sdl.Color) {
+	steps := int(math.Ceil(max(math.Abs(to.X()-from.X()), math.Abs(to.Y()-from.Y()))))
+	for i := range steps + 1 {
+		t := float64(i) / float64(max(steps, 1))
+		x := from.X() + (to.X()-from.X())*t
+		y := from.Y() + (to.Y()-from.Y())*t
+		renderer.FillRect(sdl.Rect{X: int32(math.Floor(x)), Y: int32(math.Floor(y)), W: 1, H: 1}, color)
 	}
-	c.position.W = width
-	c.position.H = height
-}
-
-func (c *TextLabelComponent) Update(deltaTime float64) {}
-
-func (c *TextLabelComponent) Render(renderer *sdl.Renderer) {
-	DrawFont(c.texture, c.position, renderer)
 }
 
 type ProjectileEmitterComponent struct {
@@ -365,11 +647,143 @@ func (c *ProjectileEmitterComponent) Initialize() {
 func (c *ProjectileEmitterComponent) Update(deltaTime float64) {
 	if c.transform.position.Sub(c.origin).Length() > float64(c.scope) {
 		if c.shouldLoop {
-			c.transform.position = c.origin
+			c.transform.Teleport(c.origin)
 		} else {
 			c.owner.Destroy()
 		}
 	}
 }
 
-func (c *ProjectileEmitterComponent) Render(renderer *sdl.Renderer) {}
+func (c *ProjectileEmitterComponent) Render(renderer Renderer) {}
+
+type SoundMode int
+
+const (
+	SOUND_LOOP SoundMode = iota
+	SOUND_ONE_SHOT
+	SOUND_ON_EVENT
+)
+
+// ParseSoundMode reads "loop", "oneShot" or "event".
+func ParseSoundMode(mode string) (SoundMode, error) {
+	switch mode {
+	case "loop":
+		return SOUND_LOOP, nil
+	case "oneShot":
+		return SOUND_ONE_SHOT, nil
+	case "event":
+		return SOUND_ON_EVENT, nil
+	}
+	return SOUND_LOOP, fmt.Errorf("unknown sound mode %q", mode)
+}
+
+type SoundEmitterComponent struct {
+	owner        *Entity
+	transform    *TransformComponent
+	soundId      string
+	mode         SoundMode
+	trigger      EventType
+	volume       float64
+	soundRange   float64
+	channel      int
+	play         uint64
+	subscription SubscriptionId
+}
+
+// NewSoundEmitterComponent plays soundId once, forever, or every time trigger
+// fires for this entity. A soundRange of 0 turns distance attenuation off.
+func NewSoundEmitterComponent(soundId string, mode SoundMode, trigger EventType, volume, soundRange float64) *SoundEmitterComponent {
+	return &SoundEmitterComponent{soundId: soundId, mode: mode, trigger: trigger, volume: volume, soundRange: soundRange, channel: -1}
+}
+
+func (c *SoundEmitterComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *SoundEmitterComponent) Initialize() {
+	if c.owner.HasComponent(TRANSFORM_COMPONENT) {
+		c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	}
+
+	events := c.owner.manager.GetEventBus()
+	switch c.mode {
+	case SOUND_LOOP:
+		c.Play(-1)
+	case SOUND_ONE_SHOT:
+		c.Play(0)
+	case SOUND_ON_EVENT:
+		c.subscription = events.SubscribeType(c.trigger, func(event Event) {
+			if Involves(event, c.owner) {
+				c.Play(0)
+			}
+		})
+	}
+
+	var destroyed SubscriptionId
+	destroyed = Subscribe(events, func(e EntityDestroyedEvent) {
+		if e.entity == c.owner {
+			c.Stop()
+			events.Unsubscribe(c.subscription)
+			events.Unsubscribe(destroyed)
+		}
+	})
+}
+
+func (c *SoundEmitterComponent) Play(loops int) {
+	audio := c.owner.manager.assetManager.GetAudio()
+	sound := c.owner.manager.assetManager.GetSound(c.soundId)
+	if audio == nil || sound == nil {
+		return
+	}
+
+	c.Stop()
+	channel, err := audio.Play(sound, loops)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	c.channel = channel
+	c.play = audio.Plays(channel)
+	c.attenuate()
+}
+
+// playing reports whether the emitter's sound still plays on its channel; once
+// it ends the mixer hands the channel to other sounds.
+func (c *SoundEmitterComponent) playing(audio AudioBackend) bool {
+	return c.channel >= 0 && audio.Plays(c.channel) == c.play && audio.Playing(c.channel)
+}
+
+func (c *SoundEmitterComponent) Stop() {
+	if audio := c.owner.manager.assetManager.GetAudio(); audio != nil && c.playing(audio) {
+		audio.Stop(c.channel)
+	}
+	c.channel = -1
+}
+
+func (c *SoundEmitterComponent) Update(deltaTime float64) {
+	if c.channel < 0 {
+		return
+	}
+	if !c.playing(c.owner.manager.assetManager.GetAudio()) {
+		c.channel = -1
+		return
+	}
+	c.attenuate()
+}
+
+func (c *SoundEmitterComponent) attenuate() {
+	audio := c.owner.manager.assetManager.GetAudio()
+	volume, pan := c.volume, 0.0
+
+	camera := c.owner.manager.camera
+	if c.transform != nil && camera != nil && c.soundRange > 0 {
+		offset := c.transform.position.Sub(camera.Center())
+		volume *= Clamp(1-offset.Length()/c.soundRange, 0, 1)
+		pan = Clamp(offset.X()/c.soundRange, -1, 1)
+	}
+
+	audio.SetVolume(c.channel, volume)
+	audio.SetPan(c.channel, pan)
+}
+
+func (c *SoundEmitterComponent) Render(renderer Renderer) {}
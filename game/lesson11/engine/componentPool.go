@@ -0,0 +1,103 @@
+package engine
+
+type EntityId uint64
+
+func NewEntityId(index, generation uint32) EntityId {
+	return EntityId(generation)<<32 | EntityId(index)
+}
+
+func (id EntityId) Index() uint32 {
+	return uint32(id)
+}
+
+func (id EntityId) Generation() uint32 {
+	return uint32(id >> 32)
+}
+
+// ComponentPool is a sparse set: sparse maps an entity index to a slot in the
+// packed dense/components slices, so iteration only touches live components.
+// The components themselves are still separate allocations.
+type ComponentPool struct {
+	sparse     []int32
+	dense      []EntityId
+	components []Component
+}
+
+func (p *ComponentPool) Set(id EntityId, component Component) {
+	index := int(id.Index())
+	for len(p.sparse) <= index {
+		p.sparse = append(p.sparse, -1)
+	}
+
+	if slot := p.sparse[index]; slot >= 0 {
+		p.dense[slot] = id
+		p.components[slot] = component
+		return
+	}
+
+	p.sparse[index] = int32(len(p.dense))
+	p.dense = append(p.dense, id)
+	p.components = append(p.components, component)
+}
+
+func (p *ComponentPool) slot(id EntityId) int32 {
+	index := int(id.Index())
+	if index >= len(p.sparse) {
+		return -1
+	}
+	slot := p.sparse[index]
+	if slot < 0 || p.dense[slot] != id {
+		return -1
+	}
+	return slot
+}
+
+func (p *ComponentPool) Has(id EntityId) bool {
+	return p.slot(id) >= 0
+}
+
+func (p *ComponentPool) Get(id EntityId) Component {
+	slot := p.slot(id)
+	if slot < 0 {
+		return nil
+	}
+	return p.components[slot]
+}
+
+func (p *ComponentPool) Remove(id EntityId) {
+	slot := p.slot(id)
+	if slot < 0 {
+		return
+	}
+
+	// Move the last component into the freed slot to keep the pool packed
+	last := int32(len(p.dense) - 1)
+	if slot != last {
+		p.dense[slot] = p.dense[last]
+		p.components[slot] = p.components[last]
+		p.sparse[p.dense[slot].Index()] = slot
+	}
+	p.sparse[id.Index()] = -1
+	p.components[last] = nil
+	p.dense = p.dense[:last]
+	p.components = p.components[:last]
+}
+
+func (p *ComponentPool) Len() int {
+	return len(p.dense)
+}
+
+func (p *ComponentPool) Entities() []EntityId {
+	return p.dense
+}
+
+func (p *ComponentPool) Components() []Component {
+	return p.components
+}
+
+func (p *ComponentPool) Clear() {
+	p.sparse = p.sparse[:0]
+	p.dense = p.dense[:0]
+	clear(p.components)
+	p.components = p.components[:0]
+}
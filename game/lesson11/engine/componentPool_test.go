@@ -0,0 +1,99 @@
+package engine
+
+import "testing"
+
+func TestComponentPool(t *testing.T) {
+	var pool ComponentPool
+	a, b, c := NewEntityId(0, 0), NewEntityId(5, 0), NewEntityId(2, 0)
+	components := map[EntityId]*TransformComponent{a: {}, b: {}, c: {}}
+	for _, id := range []EntityId{a, b, c} {
+		pool.Set(id, components[id])
+	}
+
+	// Removing from the middle moves the last component into the hole
+	pool.Remove(b)
+	if pool.Len() != 2 || pool.Has(b) || pool.Get(b) != nil {
+		t.Fatalf("pool still has removed %v", b)
+	}
+	if ids := pool.Entities(); ids[0] != a || ids[1] != c || pool.Components()[1] != components[c] {
+		t.Errorf("pool is %v after removing %v, want %v then %v", ids, b, a, c)
+	}
+	if pool.Get(c) != components[c] {
+		t.Error("the moved component can't be found by its id")
+	}
+
+	// An id of a later generation at the same index isn't the old one
+	stale := NewEntityId(2, 1)
+	if pool.Has(stale) || pool.Get(stale) != nil {
+		t.Errorf("%v found %v's component", stale, c)
+	}
+	pool.Remove(stale)
+	if !pool.Has(c) {
+		t.Errorf("removing %v removed %v", stale, c)
+	}
+	pool.Remove(c)
+	pool.Remove(c)
+	if pool.Len() != 1 {
+		t.Errorf("removing twice left %d components, want 1", pool.Len())
+	}
+}
+
+func TestEntityIdReuse(t *testing.T) {
+	manager := newTestManager()
+	first := manager.AddEntity("first", ENEMY_LAYER)
+	first.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 1, 1, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	id := first.Id()
+	first.Destroy()
+	manager.DestroyInactiveEntities()
+
+	second := manager.AddEntity("second", ENEMY_LAYER)
+	if second.Id().Index() != id.Index() || second.Id().Generation() != id.Generation()+1 {
+		t.Errorf("new entity got %v, want %v's index a generation later", second.Id(), id)
+	}
+	if manager.GetEntity(id) != nil {
+		t.Errorf("stale id %v still finds an entity", id)
+	}
+	if manager.GetEntity(second.Id()) != second || second.HasComponent(TRANSFORM_COMPONENT) {
+		t.Error("new entity inherited the destroyed one's components")
+	}
+}
+
+func TestQueryInto(t *testing.T) {
+	manager := newTestManager()
+	for i := range 4 {
+		entity := manager.AddEntity("entity", ENEMY_LAYER)
+		entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 1, 1, Vec2{1, 1}), TRANSFORM_COMPONENT)
+		if i%2 == 0 {
+			entity.AddComponent(NewColliderComponent("ENEMY", 0, 0, 1, 1), COLLIDER_COMPONENT)
+		}
+	}
+
+	buffer := make([]*Entity, 0, 8)
+	result := manager.QueryInto(buffer, TRANSFORM_COMPONENT, COLLIDER_COMPONENT)
+	if len(result) != 2 || &result[:1][0] != &buffer[:1][0] {
+		t.Errorf("query returned %d entities, want 2 in the caller's buffer", len(result))
+	}
+	for _, entity := range result {
+		if !entity.HasComponent(COLLIDER_COMPONENT) {
+			t.Errorf("query returned %v, which has no collider", entity.Id())
+		}
+	}
+	if allocs := testing.AllocsPerRun(10, func() { buffer = manager.QueryInto(buffer, TRANSFORM_COMPONENT) }); allocs != 0 {
+		t.Errorf("query into a big enough buffer allocated %v times", allocs)
+	}
+}
+
+// BenchmarkUpdate updates 20000 moving entities with colliders, the scale a
+// 60 FPS frame has to fit in 16ms.
+func BenchmarkUpdate(b *testing.B) {
+	manager := newTestManager()
+	for i := range 20000 {
+		entity := manager.AddEntity("entity", ENEMY_LAYER)
+		entity.AddComponent(NewTransformComponent(Vec2{float64(i), 0}, Vec2{1, 1}, 8, 8, Vec2{1, 1}), TRANSFORM_COMPONENT)
+		entity.AddComponent(NewColliderComponent("ENEMY", i, 0, 8, 8), COLLIDER_COMPONENT)
+	}
+	b.ResetTimer()
+	for range b.N {
+		manager.Update(1.0 / 60)
+	}
+}
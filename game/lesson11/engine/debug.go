@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	DEBUG_KEY            = sdl.K_F1
+	DEBUG_FONT_SIZE      = 12
+	DEBUG_STATS_INTERVAL = 0.5
+	DEBUG_MARGIN         = 8
+)
+
+var (
+	DEBUG_COLLIDER_COLOR  = sdl.Color{R: 255, G: 64, B: 64, A: 255}
+	DEBUG_BOUNDS_COLOR    = sdl.Color{R: 64, G: 160, B: 255, A: 255}
+	DEBUG_DEAD_ZONE_COLOR = sdl.Color{R: 255, G: 220, B: 64, A: 160}
+	DEBUG_SELECTED_COLOR  = sdl.Color{R: 64, G: 255, B: 64, A: 255}
+	DEBUG_PANEL_COLOR     = sdl.Color{A: 180}
+)
+
+// DebugOverlay is toggled with DEBUG_KEY. It outlines colliders, the camera
+// bounds and dead zone, shows frame rate and entity counts, and inspects the
+// entity clicked on. Its labels live in a scope of their own, so they never
+// show up among the game's entities.
+type DebugOverlay struct {
+	sceneScope
+	game      *Game
+	enabled   bool
+	stats     *TextLabelComponent
+	inspector *TextLabelComponent
+	selected  *Entity
+	frames    int
+	elapsed   float64
+	fps       float64
+	frameTime float64
+	lastFrame uint64
+}
+
+func NewDebugOverlay(g *Game) *DebugOverlay {
+	return &DebugOverlay{game: g}
+}
+
+func (d *DebugOverlay) IsEnabled() bool {
+	return d.enabled
+}
+
+func (d *DebugOverlay) Toggle() error {
+	if d.manager == nil {
+		if err := d.load(); err != nil {
+			return err
+		}
+	}
+	d.enabled = !d.enabled
+	return nil
+}
+
+func (d *DebugOverlay) load() error {
+	d.open(d.game, nil)
+	if err := d.assetManager.AddFont("debug-font", filepath.Join(rootpath, "assets/fonts/arial.ttf"), DEBUG_FONT_SIZE); err != nil {
+		return err
+	}
+
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	d.stats = NewTextLabelComponent(DEBUG_MARGIN, DEBUG_MARGIN, "", "debug-font", white)
+	d.manager.AddEntity("debugStats", UI_LAYER).AddComponent(d.stats, TEXT_LABEL_COMPONENT)
+	d.inspector = NewTextLabelComponent(WINDOW_WIDTH-DEBUG_MARGIN, DEBUG_MARGIN, "", "debug-font", white)
+	d.manager.AddEntity("debugInspector", UI_LAYER).AddComponent(d.inspector, TEXT_LABEL_COMPONENT)
+	return d.inspector.SetAlignment(ALIGN_RIGHT)
+}
+
+func (d *DebugOverlay) Close() {
+	d.close()
+	d.manager, d.selected = nil, nil
+}
+
+// HandleEvent toggles the overlay and, while it is shown, selects the entity
+// under a left click.
+func (d *DebugOverlay) HandleEvent(event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		if t.Type == sdl.KEYDOWN && t.Repeat == 0 && t.Keysym.Sym == DEBUG_KEY {
+			if err := d.Toggle(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	case *sdl.MouseButtonEvent:
+		if d.enabled && t.Type == sdl.MOUSEBUTTONDOWN && int(t.Button) == sdl.BUTTON_LEFT {
+			d.selected = d.entityAt(t.X, t.Y)
+		}
+	}
+}
+
+// baseScene is the scene at the bottom of what is on screen, the one the
+// overlay inspects.
+func (d *DebugOverlay) baseScene() Scene {
+	if scenes := d.game.visibleScenes(); len(scenes) > 0 {
+		return scenes[0]
+	}
+	return nil
+}
+
+// entityAt finds the topmost enabled entity covering a screen point.
+func (d *DebugOverlay) entityAt(x, y int32) *Entity {
+	scene := d.baseScene()
+	if scene == nil {
+		return nil
+	}
+	manager := scene.Manager()
+	for layer := NUM_LAYERS - 1; layer >= 0; layer-- {
+		entities := manager.GetEntitiesByLayer(LayerType(layer))
+		for i := len(entities) - 1; i >= 0; i-- {
+			if box, ok := d.screenBox(entities[i]); ok && entities[i].enabled &&
+				x >= box.X && x < box.X+box.W && y >= box.Y && y < box.Y+box.H {
+				return entities[i]
+			}
+		}
+	}
+	return nil
+}
+
+// screenBox is where an entity is drawn, from its sprite or else its collider.
+func (d *DebugOverlay) screenBox(entity *Entity) (sdl.Rect, bool) {
+	if entity.HasComponent(SPRITE_COMPONENT) {
+		return entity.GetComponent(SPRITE_COMPONENT).(*SpriteComponent).destinationRectangle, true
+	}
+	if entity.HasComponent(COLLIDER_COMPONENT) && entity.manager.camera != nil {
+		return entity.manager.camera.ToScreen(entity.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent).collider), true
+	}
+	if entity.HasComponent(TEXT_LABEL_COMPONENT) {
+		return entity.GetComponent(TEXT_LABEL_COMPONENT).(*TextLabelComponent).Bounds(), true
+	}
+	return sdl.Rect{}, false
+}
+
+// Frame counts a presented frame towards the frame rate.
+func (d *DebugOverlay) Frame() {
+	now := sdl.GetPerformanceCounter()
+	if d.lastFrame != 0 {
+		d.frames++
+		d.elapsed += float64(now-d.lastFrame) / float64(sdl.GetPerformanceFrequency())
+		if d.elapsed >= DEBUG_STATS_INTERVAL {
+			d.fps = float64(d.frames) / d.elapsed
+			d.frameTime = d.elapsed / float64(d.frames) * 1000
+			d.frames, d.elapsed = 0, 0
+		}
+	}
+	d.lastFrame = now
+}
+
+// inspected is the entity manager of the base scene, if there is one.
+func (d *DebugOverlay) inspected() *EntityManager {
+	if scene := d.baseScene(); scene != nil {
+		return scene.Manager()
+	}
+	return nil
+}
+
+// Update refreshes the stats and inspector once a frame, after the scenes
+// have stepped.
+func (d *DebugOverlay) Update() {
+	if !d.enabled || d.manager == nil {
+		return
+	}
+	manager := d.inspected()
+	if d.selected != nil && (!d.selected.IsActive() || d.selected.manager != manager) {
+		d.selected = nil
+	}
+	d.setText(d.stats, d.statsText(manager))
+	d.setText(d.inspector, d.inspectorText())
+}
+
+func (d *DebugOverlay) Render(renderer Renderer) {
+	if !d.enabled || d.manager == nil {
+		return
+	}
+
+	if manager := d.inspected(); manager != nil && manager.camera != nil {
+		camera := manager.camera
+		if bounds := camera.Bounds(); bounds.W > 0 && bounds.H > 0 {
+			renderer.DrawRect(camera.ToScreen(bounds), DEBUG_BOUNDS_COLOR)
+		}
+		renderer.DrawRect(camera.DeadZone(), DEBUG_DEAD_ZONE_COLOR)
+	}
+	if d.selected != nil {
+		if box, ok := d.screenBox(d.selected); ok {
+			renderer.DrawRect(box, DEBUG_SELECTED_COLOR)
+		}
+	}
+
+	for _, label := range []*TextLabelComponent{d.stats, d.inspector} {
+		if bounds := label.Bounds(); bounds.W > 0 {
+			renderer.FillRect(sdl.Rect{X: bounds.X - 4, Y: bounds.Y - 4, W: bounds.W + 8, H: bounds.H + 8}, DEBUG_PANEL_COLOR)
+		}
+	}
+	d.manager.Render(0)
+}
+
+func (d *DebugOverlay) setText(label *TextLabelComponent, text string) {
+	if err := label.SetText(text); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (d *DebugOverlay) statsText(manager *EntityManager) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%.0f FPS  %.2f ms", d.fps, d.frameTime)
+	if manager == nil {
+		return text.String()
+	}
+	fmt.Fprintf(&text, "\n%d entities, %d particles", manager.GetEntityCount(), manager.GetParticleSystem().Count())
+	for layer := range NUM_LAYERS {
+		fmt.Fprintf(&text, "\n%s: %d", LayerType(layer), len(manager.GetEntitiesByLayer(LayerType(layer))))
+	}
+	return text.String()
+}
+
+func (d *DebugOverlay) inspectorText() string {
+	if d.selected == nil {
+		return "Click an entity to inspect it"
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s (%s)", d.selected.name, d.selected.layer)
+	for typ := range NUM_COMPONENT_TYPES {
+		if component := d.selected.GetComponent(typ); component != nil {
+			fmt.Fprintf(&text, "\n%s%s", typ, describeComponent(component))
+		}
+	}
+	return text.String()
+}
+
+// describeComponent lists a component's plain value fields; references to
+// other objects are left out.
+func describeComponent(component Component) string {
+	value := reflect.Indirect(reflect.ValueOf(component))
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+
+	var text strings.Builder
+	for i := range value.NumField() {
+		field := value.Field(i)
+		switch field.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			continue
+		}
+		fmt.Fprintf(&text, "\n  %s: %v", value.Type().Field(i).Name, field)
+	}
+	return text.String()
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+func TestDebugOverlay(t *testing.T) {
+	if err := ttf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer ttf.Quit()
+
+	renderer, manager := newHeadlessManager(t, 200, 100)
+	scene := &stepScene{}
+	scene.manager = manager
+	g := &Game{renderer: renderer, input: manager.input, audio: NewNullAudioBackend(), scenes: []Scene{scene}}
+	g.debug = NewDebugOverlay(g)
+	manager.debug = g.debug
+	if err := g.debug.Toggle(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.debug.Close()
+
+	crate := manager.AddEntity("crate", OBSTACLE_LAYER)
+	crate.AddComponent(NewTransformComponent(Vec2{20, 20}, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	crate.AddComponent(NewColliderComponent("OBSTACLE", 0, 0, 16, 16), COLLIDER_COMPONENT)
+	manager.Update(0)
+
+	g.debug.HandleEvent(&sdl.MouseButtonEvent{Type: sdl.MOUSEBUTTONDOWN, Button: sdl.BUTTON_LEFT, X: 25, Y: 25})
+	g.debug.Update()
+	if text := g.debug.inspector.Text(); !strings.HasPrefix(text, "crate (OBSTACLE)\nTransform") {
+		t.Errorf("inspector shows %q", text)
+	}
+	if text := g.debug.stats.Text(); !strings.Contains(text, "\n1 entities, 0 particles\n") {
+		t.Errorf("stats show %q", text)
+	}
+
+	manager.Render(1)
+	if got := renderer.Frame().RGBAAt(20, 28); got.R != DEBUG_COLLIDER_COLOR.R || got.G != DEBUG_COLLIDER_COLOR.G {
+		t.Errorf("collider outline is %v", got)
+	}
+	// Drawing the overlay leaves its labels alone
+	stats := g.debug.stats.Text()
+	g.debug.Render(renderer)
+	if g.debug.stats.Text() != stats {
+		t.Error("rendering the overlay changed its stats")
+	}
+
+	crate.Destroy()
+	manager.DestroyInactiveEntities()
+	g.debug.Update()
+	if g.debug.selected != nil {
+		t.Error("a destroyed entity stayed selected")
+	}
+}
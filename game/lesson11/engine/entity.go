@@ -1,51 +1,125 @@
 package engine
 
-import (
-	"github.com/veandco/go-sdl2/sdl"
-)
+import "fmt"
 
 type Entity struct {
-	manager          *EntityManager
-	isActive         bool
-	components       []Component
-	componentTypeMap map[ComponentType]Component
-	name             string
-	layer            LayerType
+	manager  *EntityManager
+	id       EntityId
+	isActive bool
+	enabled  bool
+	name     string
+	layer    LayerType
+	parent   *Entity
+	children []*Entity
 }
 
 func (e *Entity) Update(deltaTime float64) {
-	for i := range e.components {
-		e.components[i].Update(deltaTime)
+	for typ := range NUM_COMPONENT_TYPES {
+		if component := e.GetComponent(ComponentType(typ)); component != nil {
+			component.Update(deltaTime)
+		}
 	}
 }
 
-func (e *Entity) Render(renderer *sdl.Renderer) {
-	for i := range e.components {
-		e.components[i].Render(renderer)
+func (e *Entity) Render(renderer Renderer) {
+	for typ := range NUM_COMPONENT_TYPES {
+		if component := e.GetComponent(ComponentType(typ)); component != nil {
+			component.Render(renderer)
+		}
 	}
 }
 
+// Destroy marks the entity, and its children with it, for removal at the end
+// of the update.
 func (e *Entity) Destroy() {
 	e.isActive = false
+	for _, child := range e.children {
+		child.Destroy()
+	}
+}
+
+// SetParent attaches the entity to parent, or detaches it given nil. A child
+// is destroyed, enabled and disabled with its parent, and its transform
+// becomes relative to the parent's, staying where it is in the world; see
+// TransformComponent.
+func (e *Entity) SetParent(parent *Entity) error {
+	for ancestor := parent; ancestor != nil; ancestor = ancestor.parent {
+		if ancestor == e {
+			return fmt.Errorf("entity %s can't be a child of its own child %s", e.name, parent.name)
+		}
+	}
+
+	if e.parent != nil {
+		e.parent.removeChild(e)
+	}
+	e.parent = parent
+	if parent != nil {
+		parent.children = append(parent.children, e)
+	}
+
+	if e.HasComponent(TRANSFORM_COMPONENT) {
+		var parentTransform *TransformComponent
+		if parent != nil && parent.HasComponent(TRANSFORM_COMPONENT) {
+			parentTransform = parent.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+		}
+		e.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent).setParent(parentTransform)
+	}
+	return nil
+}
+
+func (e *Entity) removeChild(child *Entity) {
+	for i, c := range e.children {
+		if c == child {
+			e.children = append(e.children[:i], e.children[i+1:]...)
+			return
+		}
+	}
+}
+
+func (e Entity) Parent() *Entity {
+	return e.parent
+}
+
+func (e Entity) Children() []*Entity {
+	return e.children
 }
 
 func (e Entity) IsActive() bool {
 	return e.isActive
 }
 
+// SetEnabled hides an entity without destroying it: disabled entities are
+// neither updated, rendered nor collided.
+func (e *Entity) SetEnabled(enabled bool) {
+	e.enabled = enabled
+	for _, child := range e.children {
+		child.SetEnabled(enabled)
+	}
+}
+
+func (e Entity) IsEnabled() bool {
+	return e.enabled
+}
+
+func (e Entity) Id() EntityId {
+	return e.id
+}
+
 func (e *Entity) AddComponent(component Component, typ ComponentType) Component {
 	component.SetOwner(e)
 	component.Initialize()
-	e.componentTypeMap[typ] = component
-	e.components = append(e.components, component)
+	e.manager.pools[typ].Set(e.id, component)
 	return component
 }
 
 func (e Entity) HasComponent(typ ComponentType) bool {
-	_, ok := e.componentTypeMap[typ]
-	return ok
+	return e.manager.pools[typ].Has(e.id)
 }
 
 func (e Entity) GetComponent(typ ComponentType) Component {
-	return e.componentTypeMap[typ]
+	return e.manager.pools[typ].Get(e.id)
+}
+
+func (e *Entity) RemoveComponent(typ ComponentType) {
+	e.manager.pools[typ].Remove(e.id)
 }
@@ -1,15 +1,33 @@
 package engine
 
 import (
-	"github.com/veandco/go-sdl2/sdl"
+	"cmp"
+	"slices"
 )
 
 type EntityManager struct {
-	renderer     *sdl.Renderer
-	event        *sdl.Event
-	camera       *sdl.Rect
+	renderer     Renderer
+	input        *Input
+	camera       *Camera
 	entities     []*Entity
+	dead         []*Entity
+	slots        []*Entity
+	generations  []uint32
+	freeIndices  []uint32
+	layers       [NUM_LAYERS][]*Entity
+	pools        [NUM_COMPONENT_TYPES]ComponentPool
+	spatialHash  *SpatialHash
+	contacts     map[collisionPairKey]CollisionPair
+	lastContacts map[collisionPairKey]CollisionPair
+	pairs        []CollisionPair
+	collisions   *CollisionMatrix
+	events       *EventBus
 	assetManager *AssetManager
+	pathFinder   *PathFinder
+	particles    *ParticleSystem
+	step         uint64
+	alpha        float64
+	debug        *DebugOverlay
 }
 
 func (m *EntityManager) ClearData() {
@@ -19,24 +37,89 @@ func (m *EntityManager) ClearData() {
 }
 
 func (m *EntityManager) Update(deltaTime float64) {
-	for i := range m.entities {
-		m.entities[i].Update(deltaTime)
+	// Each component type is updated as one pass over its packed pool
+	for typ := range NUM_COMPONENT_TYPES {
+		entities := m.pools[typ].Entities()
+		for i, component := range m.pools[typ].Components() {
+			if m.slots[entities[i].Index()].enabled {
+				component.Update(deltaTime)
+			}
+		}
+		// Children follow where their parents moved before anything else
+		// reads their positions
+		if typ == TRANSFORM_COMPONENT {
+			m.step++
+			for _, component := range m.pools[typ].Components() {
+				component.(*TransformComponent).resolve(m.step)
+			}
+		}
+	}
+	if m.particles != nil {
+		m.particles.Update(deltaTime)
 	}
 	m.DestroyInactiveEntities()
 }
 
+// DestroyInactiveEntities drops inactive entities from the lists before
+// announcing them, so subscribers may add entities of their own.
 func (m *EntityManager) DestroyInactiveEntities() {
-	for i := range m.entities {
-		if !m.entities[i].IsActive() {
-			m.entities = append(m.entities[:i], m.entities[i+1:]...)
+	dead := m.dead[:0]
+	alive := m.entities[:0]
+	for _, entity := range m.entities {
+		if entity.IsActive() {
+			alive = append(alive, entity)
+		} else {
+			dead = append(dead, entity)
 		}
 	}
+	if len(dead) == 0 {
+		return
+	}
+	clear(m.entities[len(alive):])
+	m.entities = alive
+
+	for layer := range m.layers {
+		remaining := m.layers[layer][:0]
+		for _, entity := range m.layers[layer] {
+			if entity.IsActive() {
+				remaining = append(remaining, entity)
+			}
+		}
+		clear(m.layers[layer][len(remaining):])
+		m.layers[layer] = remaining
+	}
+
+	for _, entity := range dead {
+		m.GetEventBus().Publish(EntityDestroyedEvent{entity})
+		if entity.parent != nil {
+			entity.parent.removeChild(entity)
+			entity.parent = nil
+		}
+		for typ := range NUM_COMPONENT_TYPES {
+			m.pools[typ].Remove(entity.id)
+		}
+
+		index := entity.id.Index()
+		m.slots[index] = nil
+		m.generations[index]++
+		m.freeIndices = append(m.freeIndices, index)
+	}
+	clear(dead)
+	m.dead = dead
 }
 
-func (m *EntityManager) Render() {
+// Render draws every layer alpha of the way between the last two fixed steps.
+// Particles go over the game world, under the UI.
+func (m *EntityManager) Render(alpha float64) {
+	m.alpha = alpha
 	for layerNumber := range NUM_LAYERS {
-		for _, entity := range m.GetEntitiesByLayer(LayerType(layerNumber)) {
-			entity.Render(m.renderer)
+		if LayerType(layerNumber) == UI_LAYER && m.particles != nil {
+			m.particles.Render(m.renderer, m.camera, alpha)
+		}
+		for _, entity := range m.layers[layerNumber] {
+			if entity.enabled {
+				entity.Render(m.renderer)
+			}
 		}
 	}
 }
@@ -46,56 +129,221 @@ func (m EntityManager) HasNoEntities() bool {
 }
 
 func (m *EntityManager) AddEntity(entityName string, layer LayerType) *Entity {
-	entity := Entity{manager: m, name: entityName, isActive: true, componentTypeMap: make(map[ComponentType]Component)}
-	m.entities = append(m.entities, &entity)
-	return &entity
+	var index uint32
+	if n := len(m.freeIndices); n > 0 {
+		index = m.freeIndices[n-1]
+		m.freeIndices = m.freeIndices[:n-1]
+	} else {
+		index = uint32(len(m.slots))
+		m.slots = append(m.slots, nil)
+		m.generations = append(m.generations, 0)
+	}
+
+	entity := &Entity{manager: m, id: NewEntityId(index, m.generations[index]), name: entityName, layer: layer, isActive: true, enabled: true}
+	m.slots[index] = entity
+	m.entities = append(m.entities, entity)
+	m.layers[layer] = append(m.layers[layer], entity)
+
+	// Queued so subscribers see the entity after its components are added
+	m.GetEventBus().Enqueue(EntityCreatedEvent{entity})
+	return entity
+}
+
+func (m EntityManager) GetEntity(id EntityId) *Entity {
+	index := int(id.Index())
+	if index >= len(m.slots) || m.slots[index] == nil || m.slots[index].id != id {
+		return nil
+	}
+	return m.slots[index]
 }
 
 func (m EntityManager) GetEntities() []*Entity {
 	return m.entities
 }
 
-func (m EntityManager) GetEntitiesByLayer(layer LayerType) []*Entity {
-	selectedEntities := []*Entity{}
+func (m EntityManager) GetEntityByName(entityName string) *Entity {
 	for _, entity := range m.entities {
-		if entity.layer == layer {
-			selectedEntities = append(selectedEntities, entity)
+		if entity.name == entityName {
+			return entity
 		}
 	}
-	return selectedEntities
+	return nil
+}
+
+func (m EntityManager) GetEntitiesByLayer(layer LayerType) []*Entity {
+	return m.layers[layer]
 }
 
 func (m EntityManager) GetEntityCount() int {
 	return len(m.entities)
 }
 
-func (m EntityManager) CheckCollisions() CollisionType {
-	for i := 0; i < len(m.entities); i++ {
-		thisEntity := m.entities[i]
-		if thisEntity.HasComponent(COLLIDER_COMPONENT) {
-			thisCollider := thisEntity.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent)
-			for j := i + 1; j < len(m.entities); j++ {
-				thatEntity := m.entities[j]
-				if thisEntity.name != thatEntity.name && thatEntity.HasComponent(COLLIDER_COMPONENT) {
-					thatCollider := thatEntity.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent)
-					if CheckRectangleCollision(thisCollider.collider, thatCollider.collider) {
-						if thisCollider.colliderTag == "PLAYER" && thatCollider.colliderTag == "ENEMY" {
-							return PLAYER_ENEMY_COLLISION
-						}
-						if thisCollider.colliderTag == "PLAYER" && thatCollider.colliderTag == "PROJECTILE" {
-							return PLAYER_PROJECTILE_COLLISION
-						}
-						if thisCollider.colliderTag == "ENEMY" && thatCollider.colliderTag == "PROJECTILE" {
-							return ENEMY_PROJECTILE_COLLISION
-						}
-						if thisCollider.colliderTag == "PLAYER" && thatCollider.colliderTag == "LEVEL_COMPLETE" {
-							return PLAYER_LEVEL_COMPLETE_COLLISION
-						}
-					}
-				}
+func (m *EntityManager) GetPool(typ ComponentType) *ComponentPool {
+	return &m.pools[typ]
+}
+
+// Query returns the entities owning every given component type. It walks the
+// smallest matching pool and probes the others, so cost scales with that pool.
+func (m *EntityManager) Query(types ...ComponentType) []*Entity {
+	return m.QueryInto(nil, types...)
+}
+
+// QueryInto is Query appending to entities[:0], so a query run every frame
+// needn't allocate.
+func (m *EntityManager) QueryInto(entities []*Entity, types ...ComponentType) []*Entity {
+	selectedEntities := entities[:0]
+	if len(types) == 0 {
+		return selectedEntities
+	}
+
+	smallest := types[0]
+	for _, typ := range types[1:] {
+		if m.pools[typ].Len() < m.pools[smallest].Len() {
+			smallest = typ
+		}
+	}
+
+	for _, id := range m.pools[smallest].Entities() {
+		matches := true
+		for _, typ := range types {
+			if !m.pools[typ].Has(id) {
+				matches = false
+				break
 			}
 		}
+		if matches {
+			selectedEntities = append(selectedEntities, m.slots[id.Index()])
+		}
+	}
+	return selectedEntities
+}
+
+// CheckCollisions reports every overlapping pair of colliders whose tags the
+// collision matrix lets interact, plus an exit for each pair that overlapped
+// last frame and no longer does, and hands them to the registered handlers.
+// The returned slice is reused by the next call.
+func (m *EntityManager) CheckCollisions() []CollisionPair {
+	if m.spatialHash == nil {
+		m.spatialHash = NewSpatialHash(COLLISION_CELL_SIZE)
+		m.contacts = make(map[collisionPairKey]CollisionPair)
+		m.lastContacts = make(map[collisionPairKey]CollisionPair)
+	}
+	m.spatialHash.Clear()
+	for _, component := range m.pools[COLLIDER_COMPONENT].Components() {
+		if collider := component.(*ColliderComponent); collider.owner.IsActive() && collider.owner.enabled {
+			m.spatialHash.Insert(collider)
+		}
+	}
+
+	collisions := m.GetCollisionMatrix()
+	m.contacts, m.lastContacts = m.lastContacts, m.contacts
+	clear(m.contacts)
+	pairs := m.pairs[:0]
+	m.spatialHash.Pairs(func(a, b *ColliderComponent) {
+		if !collisions.Collides(a.colliderTag, b.colliderTag) || firedBy(a.owner, b.owner) || firedBy(b.owner, a.owner) {
+			return
+		}
+
+		collisionType, swapped := collisions.Classify(a.colliderTag, b.colliderTag)
+		if swapped {
+			a, b = b, a
+		}
+
+		key := newCollisionPairKey(a.owner.id, b.owner.id)
+		pair := CollisionPair{this: a.owner, that: b.owner, state: COLLISION_ENTER, collisionType: collisionType}
+		if _, ok := m.lastContacts[key]; ok {
+			pair.state = COLLISION_STAY
+		}
+		m.contacts[key] = pair
+		pairs = append(pairs, pair)
+	})
+
+	// Maps and the spatial hash go in no particular order, so the pairs are
+	// sorted to run the handlers the same way every time the game is replayed
+	slices.SortFunc(pairs, compareCollisionPairs)
+	touching := len(pairs)
+	for key, pair := range m.lastContacts {
+		if _, ok := m.contacts[key]; !ok {
+			pair.state = COLLISION_EXIT
+			pairs = append(pairs, pair)
+		}
+	}
+	slices.SortFunc(pairs[touching:], compareCollisionPairs)
+	m.pairs = pairs
+
+	collisions.Dispatch(pairs)
+
+	events := m.GetEventBus()
+	for _, pair := range pairs {
+		switch pair.state {
+		case COLLISION_ENTER:
+			events.Publish(CollisionEnterEvent{pair})
+		case COLLISION_EXIT:
+			events.Publish(CollisionExitEvent{pair})
+		}
+	}
+	return pairs
+}
+
+func compareCollisionPairs(a, b CollisionPair) int {
+	if c := cmp.Compare(a.this.id, b.this.id); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.that.id, b.that.id)
+}
+
+func (m *EntityManager) GetEventBus() *EventBus {
+	if m.events == nil {
+		m.events = NewEventBus()
+	}
+	return m.events
+}
+
+// MapSize is the size in world pixels of the largest loaded tilemap.
+func (m *EntityManager) MapSize() (int, int) {
+	width, height := 0, 0
+	for _, entity := range m.Query(TILEMAP_COMPONENT) {
+		tilemapWidth, tilemapHeight := entity.GetComponent(TILEMAP_COMPONENT).(*TilemapComponent).PixelSize()
+		width, height = max(width, tilemapWidth), max(height, tilemapHeight)
+	}
+	return width, height
+}
+
+func (m *EntityManager) GetCamera() *Camera {
+	return m.camera
+}
+
+func (m *EntityManager) GetParticleSystem() *ParticleSystem {
+	if m.particles == nil {
+		m.particles = NewParticleSystem()
+	}
+	return m.particles
+}
+
+// BaseTilemap is the tilemap of TILEMAP_LAYER, the ground paths are found
+// on, if the level has one.
+func (m *EntityManager) BaseTilemap() *TilemapComponent {
+	for _, entity := range m.layers[TILEMAP_LAYER] {
+		if entity.IsActive() && entity.HasComponent(TILEMAP_COMPONENT) {
+			return entity.GetComponent(TILEMAP_COMPONENT).(*TilemapComponent)
+		}
+	}
+	return nil
+}
+
+// GetPathFinder searches routes over the level's tilemap; nil when the level
+// has none.
+func (m *EntityManager) GetPathFinder() *PathFinder {
+	return m.pathFinder
+}
+
+func (m *EntityManager) SetPathFinder(pathFinder *PathFinder) {
+	m.pathFinder = pathFinder
+}
 
+func (m *EntityManager) GetCollisionMatrix() *CollisionMatrix {
+	if m.collisions == nil {
+		m.collisions = NewDefaultCollisionMatrix()
 	}
-	return NO_COLLISION
+	return m.collisions
 }
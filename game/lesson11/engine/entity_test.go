@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b Vec2) bool {
+	return math.Abs(a.X()-b.X()) < 1e-9 && math.Abs(a.Y()-b.Y()) < 1e-9
+}
+
+func TestSetParent(t *testing.T) {
+	_, manager := newHeadlessManager(t, 64, 48)
+	parent := manager.AddEntity("parent", PLAYER_LAYER)
+	parentTransform := NewTransformComponent(Vec2{100, 100}, Vec2{10, 0}, 20, 20, Vec2{2, 2})
+	parentTransform.rotation = 90
+	parent.AddComponent(parentTransform, TRANSFORM_COMPONENT)
+
+	// Attached at runtime, a child stays where it is in the world
+	child := manager.AddEntity("child", PLAYER_LAYER)
+	childTransform := NewTransformComponent(Vec2{150, 120}, Vec2{}, 10, 10, Vec2{2, 2})
+	childTransform.rotation = 30
+	child.AddComponent(childTransform, TRANSFORM_COMPONENT)
+	center := childTransform.Center()
+	if err := child.SetParent(parent); err != nil {
+		t.Fatal(err)
+	}
+	if !near(childTransform.Center(), center) || childTransform.rotation != 30 || childTransform.scale != (Vec2{2, 2}) {
+		t.Errorf("attached child is at %v turned %v scaled %v, want it left at %v turned 30 scaled 2",
+			childTransform.Center(), childTransform.rotation, childTransform.scale, center)
+	}
+
+	// Added to an entity that has a parent already, as the loader does, its
+	// position is the offset of its center in the parent's space
+	loaded := manager.AddEntity("loaded", PLAYER_LAYER)
+	if err := loaded.SetParent(parent); err != nil {
+		t.Fatal(err)
+	}
+	loadedTransform := NewTransformComponent(Vec2{5, 0}, Vec2{}, 10, 10, Vec2{1, 1})
+	loaded.AddComponent(loadedTransform, TRANSFORM_COMPONENT)
+	if want := parentTransform.Center().Add(Vec2{0, 10}); !near(loadedTransform.Center(), want) {
+		t.Errorf("loaded child is at %v, want %v", loadedTransform.Center(), want)
+	}
+
+	manager.Update(1)
+	if want := center.Add(Vec2{10, 0}); !near(childTransform.Center(), want) {
+		t.Errorf("child is at %v after its parent moved 10 right, want %v", childTransform.Center(), want)
+	}
+
+	child.Destroy()
+	manager.Update(0)
+	if len(parent.Children()) != 1 || child.Parent() != nil {
+		t.Errorf("destroyed child left %d children", len(parent.Children()))
+	}
+	parent.Destroy()
+	if loaded.IsActive() {
+		t.Error("child outlived its parent")
+	}
+}
@@ -0,0 +1,222 @@
+package engine
+
+import "github.com/veandco/go-sdl2/sdl"
+
+type EventType int
+
+const (
+	COLLISION_ENTER_EVENT EventType = iota
+	COLLISION_EXIT_EVENT
+	KEY_PRESSED_EVENT
+	KEY_RELEASED_EVENT
+	ENTITY_CREATED_EVENT
+	ENTITY_DESTROYED_EVENT
+	LEVEL_COMPLETE_EVENT
+	GAME_OVER_EVENT
+	CAMERA_SHAKE_EVENT
+	ENTITY_DAMAGED_EVENT
+	ENTITY_KILLED_EVENT
+	PROJECTILE_FIRED_EVENT
+	NUM_EVENT_TYPES
+)
+
+var eventTypeNames = map[string]EventType{
+	"COLLISION_ENTER":  COLLISION_ENTER_EVENT,
+	"COLLISION_EXIT":   COLLISION_EXIT_EVENT,
+	"KEY_PRESSED":      KEY_PRESSED_EVENT,
+	"KEY_RELEASED":     KEY_RELEASED_EVENT,
+	"ENTITY_CREATED":   ENTITY_CREATED_EVENT,
+	"ENTITY_DESTROYED": ENTITY_DESTROYED_EVENT,
+	"LEVEL_COMPLETE":   LEVEL_COMPLETE_EVENT,
+	"GAME_OVER":        GAME_OVER_EVENT,
+	"CAMERA_SHAKE":     CAMERA_SHAKE_EVENT,
+	"ENTITY_DAMAGED":   ENTITY_DAMAGED_EVENT,
+	"ENTITY_KILLED":    ENTITY_KILLED_EVENT,
+	"PROJECTILE_FIRED": PROJECTILE_FIRED_EVENT,
+}
+
+func EventTypeFromName(name string) (EventType, bool) {
+	typ, ok := eventTypeNames[name]
+	return typ, ok
+}
+
+// Involves reports whether an event concerns the given entity. Events not tied
+// to an entity involve everyone.
+func Involves(event Event, entity *Entity) bool {
+	switch e := event.(type) {
+	case CollisionEnterEvent:
+		return e.pair.this == entity || e.pair.that == entity
+	case CollisionExitEvent:
+		return e.pair.this == entity || e.pair.that == entity
+	case EntityCreatedEvent:
+		return e.entity == entity
+	case EntityDestroyedEvent:
+		return e.entity == entity
+	case EntityDamagedEvent:
+		return e.entity == entity
+	case EntityKilledEvent:
+		return e.entity == entity
+	case ProjectileFiredEvent:
+		return e.shooter == entity
+	}
+	return true
+}
+
+type Event interface {
+	Type() EventType
+}
+
+type CollisionEnterEvent struct {
+	pair CollisionPair
+}
+
+func (e CollisionEnterEvent) Type() EventType { return COLLISION_ENTER_EVENT }
+
+type CollisionExitEvent struct {
+	pair CollisionPair
+}
+
+func (e CollisionExitEvent) Type() EventType { return COLLISION_EXIT_EVENT }
+
+type KeyPressedEvent struct {
+	key    sdl.Keycode
+	name   string
+	repeat bool
+}
+
+func (e KeyPressedEvent) Type() EventType { return KEY_PRESSED_EVENT }
+
+type KeyReleasedEvent struct {
+	key  sdl.Keycode
+	name string
+}
+
+func (e KeyReleasedEvent) Type() EventType { return KEY_RELEASED_EVENT }
+
+type EntityCreatedEvent struct {
+	entity *Entity
+}
+
+func (e EntityCreatedEvent) Type() EventType { return ENTITY_CREATED_EVENT }
+
+type EntityDestroyedEvent struct {
+	entity *Entity
+}
+
+func (e EntityDestroyedEvent) Type() EventType { return ENTITY_DESTROYED_EVENT }
+
+type LevelCompleteEvent struct {
+	levelNumber int
+}
+
+func (e LevelCompleteEvent) Type() EventType { return LEVEL_COMPLETE_EVENT }
+
+type GameOverEvent struct {
+	cause *Entity
+}
+
+func (e GameOverEvent) Type() EventType { return GAME_OVER_EVENT }
+
+// CameraShakeEvent adds trauma, in [0, 1], to the camera.
+type CameraShakeEvent struct {
+	trauma float64
+}
+
+func (e CameraShakeEvent) Type() EventType { return CAMERA_SHAKE_EVENT }
+
+type EntityDamagedEvent struct {
+	entity *Entity
+	source *Entity
+	amount int
+}
+
+func (e EntityDamagedEvent) Type() EventType { return ENTITY_DAMAGED_EVENT }
+
+type EntityKilledEvent struct {
+	entity *Entity
+	source *Entity
+}
+
+func (e EntityKilledEvent) Type() EventType { return ENTITY_KILLED_EVENT }
+
+type ProjectileFiredEvent struct {
+	shooter    *Entity
+	projectile *Entity
+}
+
+func (e ProjectileFiredEvent) Type() EventType { return PROJECTILE_FIRED_EVENT }
+
+type SubscriptionId int
+
+type subscription struct {
+	id      SubscriptionId
+	handler func(Event)
+}
+
+// EventBus delivers events to subscribers either immediately with Publish or
+// at the next Flush with Enqueue.
+type EventBus struct {
+	subscribers [NUM_EVENT_TYPES][]subscription
+	queue       []Event
+	nextId      SubscriptionId
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a handler for the event type T, e.g.
+// Subscribe(bus, func(e KeyPressedEvent) { ... }).
+func Subscribe[T Event](bus *EventBus, handler func(T)) SubscriptionId {
+	var zero T
+	return bus.SubscribeType(zero.Type(), func(event Event) {
+		handler(event.(T))
+	})
+}
+
+// SubscribeType registers an untyped handler, for subscribers that pick the
+// event type at runtime, e.g. from level data.
+func (bus *EventBus) SubscribeType(typ EventType, handler func(Event)) SubscriptionId {
+	bus.nextId++
+	bus.subscribers[typ] = append(bus.subscribers[typ], subscription{bus.nextId, handler})
+	return bus.nextId
+}
+
+func (bus *EventBus) Unsubscribe(id SubscriptionId) {
+	for typ := range bus.subscribers {
+		subscribers := bus.subscribers[typ]
+		for i := range subscribers {
+			if subscribers[i].id == id {
+				bus.subscribers[typ] = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (bus *EventBus) Publish(event Event) {
+	for _, subscriber := range bus.subscribers[event.Type()] {
+		subscriber.handler(event)
+	}
+}
+
+func (bus *EventBus) Enqueue(event Event) {
+	bus.queue = append(bus.queue, event)
+}
+
+// Flush delivers queued events in order, including any queued while flushing.
+func (bus *EventBus) Flush() {
+	for i := 0; i < len(bus.queue); i++ {
+		bus.Publish(bus.queue[i])
+	}
+	clear(bus.queue)
+	bus.queue = bus.queue[:0]
+}
+
+func (bus *EventBus) Clear() {
+	for typ := range bus.subscribers {
+		bus.subscribers[typ] = nil
+	}
+	clear(bus.queue)
+	bus.queue = bus.queue[:0]
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPublishAndFlush(t *testing.T) {
+	bus := NewEventBus()
+	var got []int
+	Subscribe(bus, func(e LevelCompleteEvent) {
+		got = append(got, e.levelNumber)
+		// Enqueued while flushing, so delivered by the same Flush
+		if e.levelNumber == 2 {
+			bus.Enqueue(LevelCompleteEvent{4})
+		}
+	})
+
+	bus.Enqueue(LevelCompleteEvent{2})
+	bus.Publish(LevelCompleteEvent{1})
+	bus.Enqueue(LevelCompleteEvent{3})
+	if !slices.Equal(got, []int{1}) {
+		t.Fatalf("before Flush got %v, want only the published 1", got)
+	}
+	bus.Flush()
+	if !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("after Flush got %v, want 1 2 3 4", got)
+	}
+	bus.Flush()
+	if len(got) != 4 {
+		t.Errorf("a second Flush delivered %v again", got[4:])
+	}
+}
+
+func TestUnsubscribeDuringPublish(t *testing.T) {
+	bus := NewEventBus()
+	var got []string
+	var second SubscriptionId
+	Subscribe(bus, func(e LevelCompleteEvent) {
+		got = append(got, "first")
+		bus.Unsubscribe(second)
+	})
+	second = Subscribe(bus, func(e LevelCompleteEvent) { got = append(got, "second") })
+	Subscribe(bus, func(e LevelCompleteEvent) { got = append(got, "third") })
+	Subscribe(bus, func(e GameOverEvent) { got = append(got, "game over") })
+
+	// The running Publish still sees the subscribers it started with
+	bus.Publish(LevelCompleteEvent{1})
+	if !slices.Equal(got, []string{"first", "second", "third"}) {
+		t.Errorf("first Publish reached %v", got)
+	}
+	got = nil
+	bus.Publish(LevelCompleteEvent{1})
+	if !slices.Equal(got, []string{"first", "third"}) {
+		t.Errorf("Publish after Unsubscribe reached %v", got)
+	}
+}
+
+func TestEntityDestroyedEvent(t *testing.T) {
+	manager := newTestManager()
+	var destroyed []string
+	Subscribe(manager.GetEventBus(), func(e EntityDestroyedEvent) {
+		destroyed = append(destroyed, e.entity.name)
+		if !e.entity.HasComponent(TRANSFORM_COMPONENT) {
+			t.Errorf("%s lost its components before the event", e.entity.name)
+		}
+		manager.AddEntity("debris", ENEMY_LAYER)
+	})
+
+	for _, name := range []string{"a", "b", "c"} {
+		entity := manager.AddEntity(name, ENEMY_LAYER)
+		entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 1, 1, Vec2{1, 1}), TRANSFORM_COMPONENT)
+		if name != "b" {
+			entity.Destroy()
+		}
+	}
+	manager.DestroyInactiveEntities()
+
+	if !slices.Equal(destroyed, []string{"a", "c"}) {
+		t.Errorf("destroyed %v, want a and c", destroyed)
+	}
+	var names []string
+	for _, entity := range manager.GetEntities() {
+		names = append(names, entity.name)
+	}
+	if !slices.Equal(names, []string{"b", "debris", "debris"}) {
+		t.Errorf("entities %v, want b and the debris added by the subscriber", names)
+	}
+	if layer := manager.layers[ENEMY_LAYER]; len(layer) != 3 {
+		t.Errorf("enemy layer has %d entities, want 3", len(layer))
+	}
+}
@@ -12,15 +12,20 @@ import (
 const (
 	FPS               = 60
 	FRAME_TARGET_TIME = 1000 / FPS
-	NUM_LAYERS        = 6
+	UPDATE_RATE       = 120
+	FIXED_TIMESTEP    = 1.0 / UPDATE_RATE
+	MAX_FRAME_TIME    = 0.25
+	NUM_LAYERS        = 7
 	WINDOW_WIDTH      = 800
 	WINDOW_HEIGHT     = 600
+
+	PLAYER_LIVES                   = 3
+	PLAYER_RESPAWN_INVULNERABILITY = 2.0
 )
 
 var (
-	basepath   string
-	rootpath   string
-	whiteColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	basepath string
+	rootpath string
 )
 
 func init() {
@@ -41,6 +46,15 @@ const (
 	UI_LAYER
 )
 
+var layerNames = [NUM_LAYERS]string{"TILEMAP", "VEGETATION", "ENEMY", "OBSTACLE", "PLAYER", "PROJECTILE", "UI"}
+
+func (l LayerType) String() string {
+	if l >= 0 && l < NUM_LAYERS {
+		return layerNames[l]
+	}
+	return fmt.Sprintf("LayerType(%d)", int(l))
+}
+
 type CollisionType int
 
 const (
@@ -50,24 +64,53 @@ const (
 	ENEMY_PROJECTILE_COLLISION
 	PLAYER_VEGETATION_COLLIDER
 	PLAYER_LEVEL_COMPLETE_COLLISION
+	ENEMY_FRIENDLY_PROJECTILE_COLLISION
 )
 
+// GameOptions pick how frames are paced. The simulation always steps at
+// UPDATE_RATE; by default frames are capped at FPS.
+type GameOptions struct {
+	// VSync paces frames to the display's refresh rate
+	VSync bool
+	// Uncapped renders as many frames as it can
+	Uncapped bool
+	// Headless draws into memory instead of a window and advances exactly one
+	// fixed step per frame, so runs are reproducible frame by frame
+	Headless bool
+	// FrameDirectory is where a headless game writes each frame as a PNG
+	FrameDirectory string
+	// StartLevel skips the menu and starts a new game at that level
+	StartLevel int
+	// HotReload watches the assets directory and reloads what changes
+	HotReload bool
+}
+
 type Game struct {
-	ticksLastFrame uint64
-	running        bool
-	window         *sdl.Window
-	renderer       *sdl.Renderer
-	event          sdl.Event
-	camera         sdl.Rect
-	manager        *EntityManager
-	assetManager   *AssetManager
-	player         *Entity
+	Options      GameOptions
+	lastFrame    uint64
+	accumulator  float64
+	running      bool
+	window       *sdl.Window
+	renderer     Renderer
+	input        *Input
+	audio        AudioBackend
+	scenes       []Scene
+	sceneChanges []func() error
+	debug        *DebugOverlay
+	watcher      *AssetWatcher
+	score        int
+	lives        int
 }
 
 func (g *Game) Initialize() error {
 	var err error
 
-	if err = sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+	if g.Options.Headless {
+		err = sdl.Init(sdl.INIT_TIMER | sdl.INIT_EVENTS)
+	} else {
+		err = sdl.Init(sdl.INIT_EVERYTHING)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to initialize SDL: %s", err)
 	}
 
@@ -75,196 +118,221 @@ func (g *Game) Initialize() error {
 		return fmt.Errorf("failed to initialize ttf: %s", err)
 	}
 
-	g.window, err = sdl.CreateWindow("", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
-		WINDOW_WIDTH, WINDOW_HEIGHT, sdl.WINDOW_BORDERLESS|sdl.WINDOW_ALLOW_HIGHDPI)
-	if err != nil {
-		return fmt.Errorf("failed to create window: %s", err)
+	if g.Options.Headless {
+		if g.renderer, err = NewImageRenderer(WINDOW_WIDTH, WINDOW_HEIGHT, g.Options.FrameDirectory); err != nil {
+			return err
+		}
+		g.audio = NewNullAudioBackend()
+	} else if err = g.createWindow(); err != nil {
+		return err
 	}
 
-	g.renderer, err = sdl.CreateRenderer(g.window, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_PRESENTVSYNC)
-	if err != nil {
-		return fmt.Errorf("failed to create renderer: %s", err)
+	g.input = NewInput()
+	if err = g.input.LoadBindings(filepath.Join(rootpath, "assets/scripts/Input.lua")); err != nil {
+		return err
+	}
+	g.debug = NewDebugOverlay(g)
+	if g.Options.HotReload {
+		g.watcher = NewAssetWatcher(filepath.Join(rootpath, "assets"))
 	}
 
-	g.camera = sdl.Rect{X: 0, Y: 0, W: WINDOW_WIDTH, H: WINDOW_HEIGHT}
-	g.assetManager = &AssetManager{renderer: g.renderer, textures: make(map[string]*sdl.Texture), fonts: make(map[string]*ttf.Font)}
-	g.manager = &EntityManager{renderer: g.renderer, event: &g.event, camera: &g.camera, assetManager: g.assetManager}
-
-	if err = g.LoadLevel(0); err != nil {
-		panic(err)
+	if g.Options.StartLevel > 0 {
+		g.score = 0
+		g.lives = PLAYER_LIVES
+		g.PushScene(NewPlayScene(g, g.Options.StartLevel))
+	} else {
+		g.PushScene(NewMenuScene(g))
+	}
+	if err = g.applySceneChanges(); err != nil {
+		return err
 	}
 
+	g.lastFrame = sdl.GetPerformanceCounter()
 	g.running = true
 
 	return nil
 }
 
-func (g *Game) LoadLevel(levelNumber int) error {
-	/* Start including new assets to the assetmanager list */
-	if err := g.assetManager.AddTexture("tank-image", filepath.Join(rootpath, "assets/images/tank-big-right.png")); err != nil {
-		return err
-	}
-
-	if err := g.assetManager.AddTexture("chopper-image", filepath.Join(rootpath, "assets/images/chopper-spritesheet.png")); err != nil {
-		return err
-	}
-
-	if err := g.assetManager.AddTexture("radar-image", filepath.Join(rootpath, "assets/images/radar.png")); err != nil {
-		return err
+func (g *Game) createWindow() error {
+	var err error
+	g.window, err = sdl.CreateWindow("", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
+		WINDOW_WIDTH, WINDOW_HEIGHT, sdl.WINDOW_BORDERLESS|sdl.WINDOW_ALLOW_HIGHDPI)
+	if err != nil {
+		return fmt.Errorf("failed to create window: %s", err)
 	}
 
-	if err := g.assetManager.AddTexture("jungle-tiletexture", filepath.Join(rootpath, "assets/tilemaps/jungle.png")); err != nil {
-		return err
+	var rendererFlags uint32 = sdl.RENDERER_ACCELERATED
+	if g.Options.VSync {
+		rendererFlags |= sdl.RENDERER_PRESENTVSYNC
 	}
-
-	if err := g.assetManager.AddTexture("heliport-image", filepath.Join(rootpath, "assets/images/heliport.png")); err != nil {
-		return err
+	renderer, err := sdl.CreateRenderer(g.window, -1, rendererFlags)
+	if err != nil {
+		return fmt.Errorf("failed to create renderer: %s", err)
 	}
+	g.renderer = NewSDLRenderer(renderer)
 
-	if err := g.assetManager.AddTexture("projectile-image", filepath.Join(rootpath, "assets/images/bullet-enemy.png")); err != nil {
-		return err
+	if g.audio, err = NewSDLAudioBackend(); err != nil {
+		fmt.Println(err, "- continuing without sound")
+		g.audio = NewNullAudioBackend()
 	}
+	return nil
+}
 
-	if err := g.assetManager.AddFont("charriot-font", filepath.Join(rootpath, "assets/fonts/charriot.ttf"), 14); err != nil {
-		return err
-	}
+// Renderer is what the game draws with, an *ImageRenderer when headless.
+func (g *Game) Renderer() Renderer {
+	return g.renderer
+}
 
-	m := Map{g.manager, g.assetManager.GetTexture("jungle-tiletexture"), 2, 32}
-	if err := m.LoadMap(filepath.Join(rootpath, "assets/tilemaps/jungle.map"), 25, 20); err != nil {
-		return err
-	}
+// Input lets a headless run feed the game events through HandleEvent.
+func (g *Game) Input() *Input {
+	return g.input
+}
 
-	/* Start including entities and also components to them */
-	g.player = g.manager.AddEntity("chopper", PLAYER_LAYER)
-	g.player.AddComponent(NewTransformComponent(Vec2{240, 106}, Vec2{0, 0}, 32, 32, 1), TRANSFORM_COMPONENT)
-	g.player.AddComponent(NewSpriteComponent2(g.assetManager.GetTexture("chopper-image"), 2, 90, true, false), SPRITE_COMPONENT)
-	g.player.AddComponent(NewKeyboardControlComponent("Up", "Right", "Down", "Left", "Space"), KEYBOARD_CONTROL_COMPONENT)
-	g.player.AddComponent(NewColliderComponent("PLAYER", 250, 106, 32, 32), COLLIDER_COMPONENT)
-
-	tankEntity := g.manager.AddEntity("tank", ENEMY_LAYER)
-	tankEntity.AddComponent(NewTransformComponent(Vec2{150, 495}, Vec2{0, 0}, 32, 32, 1), TRANSFORM_COMPONENT)
-	tankEntity.AddComponent(NewSpriteComponent(g.assetManager.GetTexture("tank-image")), SPRITE_COMPONENT)
-	tankEntity.AddComponent(NewColliderComponent("ENEMY", 150, 495, 32, 32), COLLIDER_COMPONENT)
-
-	projectile := g.manager.AddEntity("projectile", PROJECTILE_LAYER)
-	projectile.AddComponent(NewTransformComponent(Vec2{150 + 16, 495 + 16}, Vec2{0, 0}, 4, 4, 1), TRANSFORM_COMPONENT)
-	projectile.AddComponent(NewSpriteComponent(g.assetManager.GetTexture("projectile-image")), SPRITE_COMPONENT)
-	projectile.AddComponent(NewColliderComponent("PROJECTILE", 150+16, 495+16, 4, 4), COLLIDER_COMPONENT)
-	projectile.AddComponent(NewProjectileEmitterComponent(50, 270, 200, true), PROJECTILE_EMITTER_COMPONENT)
-
-	heliport := g.manager.AddEntity("heliport", OBSTACLE_LAYER)
-	heliport.AddComponent(NewTransformComponent(Vec2{470, 420}, Vec2{0, 0}, 32, 32, 1), TRANSFORM_COMPONENT)
-	heliport.AddComponent(NewSpriteComponent(g.assetManager.GetTexture("heliport-image")), SPRITE_COMPONENT)
-	heliport.AddComponent(NewColliderComponent("LEVEL_COMPLETE", 470, 420, 32, 32), COLLIDER_COMPONENT)
-
-	radarEntity := g.manager.AddEntity("radar", UI_LAYER)
-	radarEntity.AddComponent(NewTransformComponent(Vec2{720, 15}, Vec2{0, 0}, 64, 64, 1), TRANSFORM_COMPONENT)
-	radarEntity.AddComponent(NewSpriteComponent2(g.assetManager.GetTexture("radar-image"), 8, 150, false, true), SPRITE_COMPONENT)
-
-	labelLevelName := g.manager.AddEntity("labelLevelName", UI_LAYER)
-	labelLevelName.AddComponent(NewTextLabelComponent(10, 10, "First Level...", "charriot-font", whiteColor), TEXT_LABEL_COMPONENT)
-	return nil
+// NewGame starts over from level 1 with full lives, dropping whatever scenes
+// are on the stack.
+func (g *Game) NewGame() {
+	g.score = 0
+	g.lives = PLAYER_LIVES
+	g.ReplaceScenes(NewPlayScene(g, 1))
 }
 
+// ProcessInput folds this frame's events into the input state. Presses are
+// kept until a fixed step has seen them, see Update.
 func (g *Game) ProcessInput() {
-	g.event = sdl.PollEvent()
-	if g.event != nil {
-		switch t := g.event.(type) {
+	// Drain every pending event so none of this frame's presses are lost
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		g.input.HandleEvent(event)
+		g.debug.HandleEvent(event)
+
+		switch t := event.(type) {
 		case *sdl.QuitEvent:
 			g.running = false
 		case *sdl.KeyboardEvent:
-			if t.Keysym.Sym == sdl.K_ESCAPE {
-				g.running = false
+			scene := g.currentScene()
+			if scene == nil {
+				break
+			}
+
+			name := sdl.GetKeyName(t.Keysym.Sym)
+			if t.Type == sdl.KEYDOWN {
+				scene.Manager().GetEventBus().Enqueue(KeyPressedEvent{t.Keysym.Sym, name, t.Repeat != 0})
+			} else {
+				scene.Manager().GetEventBus().Enqueue(KeyReleasedEvent{t.Keysym.Sym, name})
+			}
+		case *sdl.RenderEvent:
+			// Baked tilemap chunks live in render targets, whose contents the driver may drop
+			if t.Type == sdl.RENDER_TARGETS_RESET || t.Type == sdl.RENDER_DEVICE_RESET {
+				for _, scene := range g.scenes {
+					for _, entity := range scene.Manager().Query(TILEMAP_COMPONENT) {
+						entity.GetComponent(TILEMAP_COMPONENT).(*TilemapComponent).Invalidate()
+					}
+				}
 			}
 		}
 	}
 }
 
+// Update runs as many fixed steps as the time since the last frame makes up
+// for, so the simulation advances the same way at any frame rate.
 func (g *Game) Update() {
-	// Sleep the execution until we reach the target frame time in milliseconds
-	timeToWait := FRAME_TARGET_TIME - (sdl.GetTicks64() - g.ticksLastFrame)
-
-	// Only call delay if we are too fast to process this frame
-	if timeToWait > 0 && timeToWait <= FRAME_TARGET_TIME {
-		sdl.Delay(uint32(timeToWait))
+	// Only wait out the frame when nothing else paces the loop
+	if !g.Options.Uncapped && !g.Options.VSync && !g.Options.Headless {
+		elapsed := g.secondsSince(g.lastFrame) * 1000
+		if timeToWait := FRAME_TARGET_TIME - elapsed; timeToWait > 0 {
+			sdl.Delay(uint32(timeToWait))
+		}
 	}
 
-	// Delta time is the difference in ticks from last frame converted to seconds
-	var deltaTime float64 = float64(sdl.GetTicks64()-g.ticksLastFrame) / 1000.0
-
-	// Clamp deltaTime to a maximum value
-	if deltaTime > 0.05 {
-		deltaTime = 0.05
+	now := sdl.GetPerformanceCounter()
+	frameTime := g.secondsSince(g.lastFrame)
+	g.lastFrame = now
+	if g.Options.Headless {
+		frameTime = FIXED_TIMESTEP
 	}
-
-	// Sets the new ticks for the current frame to be used in the next pass
-	g.ticksLastFrame = sdl.GetTicks64()
-
-	g.manager.Update(deltaTime)
-	g.HandleCameraMovement()
-	g.CheckCollisions()
+	g.reloadAssets(frameTime)
+	if g.advance(frameTime) {
+		g.lastFrame = sdl.GetPerformanceCounter()
+	}
+	g.debug.Update()
 }
 
-func (g *Game) HandleCameraMovement() {
-	mainPlayerTransform := g.player.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
-
-	g.camera.X = int32(mainPlayerTransform.position.X() - (WINDOW_WIDTH / 2))
-	g.camera.Y = int32(mainPlayerTransform.position.Y() - (WINDOW_HEIGHT / 2))
-
-	if g.camera.X < 0 {
-		g.camera.X = 0
+// advance steps the current scene through frameTime seconds. It stops early,
+// and reports so, when the scene stack changes.
+func (g *Game) advance(frameTime float64) bool {
+	// Cap the frame time so a hitch doesn't trigger a spiral of catch-up steps
+	g.accumulator += min(frameTime, MAX_FRAME_TIME)
+	for g.accumulator >= FIXED_TIMESTEP {
+		if scene := g.currentScene(); scene != nil {
+			scene.Update(FIXED_TIMESTEP)
+		}
+		// Presses count once, in the first step that sees them
+		g.input.BeginFrame()
+		g.accumulator -= FIXED_TIMESTEP
+
+		// A new scene starts on a fresh clock rather than catching up on the
+		// time spent loading it
+		if len(g.sceneChanges) > 0 {
+			if err := g.applySceneChanges(); err != nil {
+				fmt.Println(err)
+				g.running = false
+			}
+			g.accumulator = 0
+			return true
+		}
 	}
+	return false
+}
 
-	if g.camera.Y < 0 {
-		g.camera.Y = 0
+// reloadAssets hands the files changed on disk to every scene that can
+// reload them.
+func (g *Game) reloadAssets(deltaTime float64) {
+	if g.watcher == nil {
+		return
 	}
-
-	if g.camera.X > g.camera.W {
-		g.camera.X = g.camera.W
+	changed := g.watcher.Update(deltaTime)
+	if len(changed) == 0 {
+		return
 	}
-
-	if g.camera.Y > g.camera.H {
-		g.camera.Y = g.camera.H
+	for _, scene := range g.scenes {
+		if reloadable, ok := scene.(Reloadable); ok {
+			if err := reloadable.Reload(changed); err != nil {
+				fmt.Println(err)
+			}
+		}
 	}
 }
 
-func (g *Game) CheckCollisions() {
-	collisionTagType := g.manager.CheckCollisions()
-
-	// Game Over
-	if collisionTagType == PLAYER_ENEMY_COLLISION {
-		fmt.Println("Game Over")
-		g.running = false
-	}
-
-	if collisionTagType == PLAYER_PROJECTILE_COLLISION {
-		fmt.Println("Game Over")
-		g.running = false
-	}
-
-	// Next Level
-	if collisionTagType == PLAYER_LEVEL_COMPLETE_COLLISION {
-		fmt.Println("Next Level")
-		g.running = false
-	}
+func (g *Game) secondsSince(counter uint64) float64 {
+	return float64(sdl.GetPerformanceCounter()-counter) / float64(sdl.GetPerformanceFrequency())
 }
 
 func (g *Game) Render() {
-	g.renderer.SetDrawColor(21, 21, 21, 255)
-	g.renderer.Clear()
+	g.renderer.Clear(sdl.Color{R: 21, G: 21, B: 21, A: 255})
 
-	if g.manager.HasNoEntities() {
-		return
+	alpha := g.accumulator / FIXED_TIMESTEP
+	for _, scene := range g.visibleScenes() {
+		scene.Render(alpha)
 	}
+	g.debug.Frame()
+	g.debug.Render(g.renderer)
 
-	g.manager.Render()
-
-	g.renderer.Present()
+	if err := g.renderer.Present(); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (g *Game) Destory() {
+	for i := len(g.scenes) - 1; i >= 0; i-- {
+		g.scenes[i].Exit()
+	}
+	g.scenes = nil
+	g.debug.Close()
+	g.input.Close()
+	g.audio.Close()
 	g.renderer.Destroy()
-	g.window.Destroy()
+	if g.window != nil {
+		g.window.Destroy()
+	}
 	sdl.Quit()
 }
 
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+type stepScene struct {
+	sceneScope
+	steps int
+}
+
+func (s *stepScene) Enter() error { return nil }
+func (s *stepScene) Exit()        {}
+func (s *stepScene) Update(deltaTime float64) {
+	s.steps++
+	s.manager.Update(deltaTime)
+}
+func (s *stepScene) Render(alpha float64) {}
+func (s *stepScene) IsOverlay() bool      { return false }
+
+func TestFixedTimestep(t *testing.T) {
+	// Each schedule adds up to 60 and a half steps
+	half := FIXED_TIMESTEP / 2
+	sixtieths := make([]float64, 30)
+	for i := range sixtieths {
+		sixtieths[i] = 1.0 / 60
+	}
+	for _, frames := range [][]float64{
+		{0.25, 0.25, half},
+		{0.1, 0.1, 0.1, 0.1, 0.1, half},
+		append(sixtieths, half),
+		{0.013, 0.2, half, 0.087, 0.2},
+	} {
+		scene := &stepScene{}
+		scene.manager = newTestManager()
+		entity := scene.manager.AddEntity("mover", ENEMY_LAYER)
+		transform := entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{100, 0}, 1, 1, Vec2{1, 1}), TRANSFORM_COMPONENT).(*TransformComponent)
+		g := &Game{input: NewInput(), scenes: []Scene{scene}}
+
+		for _, frameTime := range frames {
+			g.advance(frameTime)
+		}
+		alpha := g.accumulator / FIXED_TIMESTEP
+		if scene.steps != 60 || math.Abs(alpha-0.5) > 1e-9 {
+			t.Errorf("%v: %d steps, alpha %v, want 60 and 0.5", frames, scene.steps, alpha)
+		}
+		if x := transform.Interpolated(alpha).X(); math.Abs(x-(50-100*half)) > 1e-9 {
+			t.Errorf("%v: drawn at %v", frames, x)
+		}
+	}
+}
+
+func TestFrameTimeCap(t *testing.T) {
+	scene := &stepScene{}
+	scene.manager = newTestManager()
+	g := &Game{input: NewInput(), scenes: []Scene{scene}}
+	g.advance(1)
+	if want := int(MAX_FRAME_TIME / FIXED_TIMESTEP); scene.steps != want {
+		t.Errorf("a 1s hitch ran %d steps, want %d", scene.steps, want)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+const DEFAULT_DAMAGE = 1
+
+// HealthComponent takes damage until it runs out, then publishes an
+// EntityKilledEvent. After each hit the entity is invulnerable for a while,
+// so a lingering overlap doesn't drain it every frame.
+type HealthComponent struct {
+	owner           *Entity
+	health          int
+	maxHealth       int
+	invulnerable    float64
+	invulnerableFor float64
+	scoreValue      int
+}
+
+func NewHealthComponent(maxHealth int, invulnerableFor float64, scoreValue int) *HealthComponent {
+	return &HealthComponent{health: maxHealth, maxHealth: maxHealth, invulnerableFor: invulnerableFor, scoreValue: scoreValue}
+}
+
+func (c *HealthComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *HealthComponent) Initialize() {}
+
+func (c *HealthComponent) Update(deltaTime float64) {
+	c.invulnerable = max(c.invulnerable-deltaTime, 0)
+}
+
+func (c *HealthComponent) Render(renderer Renderer) {}
+
+func (c *HealthComponent) Health() int {
+	return c.health
+}
+
+func (c *HealthComponent) MaxHealth() int {
+	return c.maxHealth
+}
+
+func (c *HealthComponent) ScoreValue() int {
+	return c.scoreValue
+}
+
+func (c *HealthComponent) IsDead() bool {
+	return c.health <= 0
+}
+
+func (c *HealthComponent) IsInvulnerable() bool {
+	return c.invulnerable > 0
+}
+
+// TakeDamage returns whether the damage landed. The hit that kills the entity
+// publishes an EntityKilledEvent naming source.
+func (c *HealthComponent) TakeDamage(amount int, source *Entity) bool {
+	if c.IsDead() || c.IsInvulnerable() || amount <= 0 {
+		return false
+	}
+
+	c.health = max(c.health-amount, 0)
+	c.invulnerable = c.invulnerableFor
+
+	events := c.owner.manager.GetEventBus()
+	events.Publish(EntityDamagedEvent{c.owner, source, amount})
+	if c.IsDead() {
+		events.Publish(EntityKilledEvent{c.owner, source})
+	}
+	return true
+}
+
+// Revive restores full health, keeping the entity invulnerable for the given
+// number of seconds.
+func (c *HealthComponent) Revive(invulnerableFor float64) {
+	c.health = c.maxHealth
+	c.invulnerable = invulnerableFor
+}
+
+type DamageComponent struct {
+	owner  *Entity
+	amount int
+}
+
+func NewDamageComponent(amount int) *DamageComponent {
+	return &DamageComponent{amount: amount}
+}
+
+func (c *DamageComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *DamageComponent) Initialize() {}
+
+func (c *DamageComponent) Update(deltaTime float64) {}
+
+func (c *DamageComponent) Render(renderer Renderer) {}
+
+// DamageOf is how much harm entity does on contact, DEFAULT_DAMAGE unless it
+// has a DamageComponent.
+func DamageOf(entity *Entity) int {
+	if entity.HasComponent(DAMAGE_COMPONENT) {
+		return entity.GetComponent(DAMAGE_COMPONENT).(*DamageComponent).amount
+	}
+	return DEFAULT_DAMAGE
+}
+
+// ApplyDamage hurts target by what source deals, if target has health.
+func ApplyDamage(target, source *Entity) bool {
+	if !target.HasComponent(HEALTH_COMPONENT) {
+		return false
+	}
+	return target.GetComponent(HEALTH_COMPONENT).(*HealthComponent).TakeDamage(DamageOf(source), source)
+}
@@ -0,0 +1,99 @@
+package engine
+
+import "testing"
+
+func addHealth(manager *EntityManager, name string, health, score int) (*Entity, *HealthComponent) {
+	entity := manager.AddEntity(name, ENEMY_LAYER)
+	entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	return entity, entity.AddComponent(NewHealthComponent(health, 0.5, score), HEALTH_COMPONENT).(*HealthComponent)
+}
+
+func TestInvulnerability(t *testing.T) {
+	manager := newTestManager()
+	_, health := addHealth(manager, "tank", 3, 0)
+	damaged := 0
+	Subscribe(manager.GetEventBus(), func(e EntityDamagedEvent) { damaged += e.amount })
+
+	if !health.TakeDamage(1, nil) || health.Health() != 2 {
+		t.Fatalf("first hit left %d health", health.Health())
+	}
+	manager.Update(0.4)
+	if health.TakeDamage(1, nil) || health.Health() != 2 {
+		t.Error("hit during the invulnerability frames landed")
+	}
+	manager.Update(0.2)
+	if !health.TakeDamage(1, nil) || health.Health() != 1 || damaged != 2 {
+		t.Errorf("hit after the invulnerability frames: %d health, %d damage published", health.Health(), damaged)
+	}
+	if health.TakeDamage(0, nil) {
+		t.Error("no damage landed")
+	}
+}
+
+func TestApplyDamage(t *testing.T) {
+	manager := newTestManager()
+	target, health := addHealth(manager, "target", 5, 0)
+	plain := manager.AddEntity("plain", ENEMY_LAYER)
+	heavy := manager.AddEntity("heavy", ENEMY_LAYER)
+	heavy.AddComponent(NewDamageComponent(3), DAMAGE_COMPONENT)
+
+	var killer *Entity
+	Subscribe(manager.GetEventBus(), func(e EntityKilledEvent) { killer = e.source })
+
+	if !ApplyDamage(target, plain) || health.Health() != 5-DEFAULT_DAMAGE {
+		t.Errorf("default damage left %d health", health.Health())
+	}
+	health.invulnerable = 0
+	if !ApplyDamage(target, heavy) || health.Health() != 1 || killer != nil {
+		t.Errorf("heavy damage left %d health", health.Health())
+	}
+	health.invulnerable = 0
+	if !ApplyDamage(target, heavy) || !health.IsDead() || killer != heavy {
+		t.Errorf("killing blow left %d health, killer %v", health.Health(), killer)
+	}
+	if ApplyDamage(plain, heavy) {
+		t.Error("damaged an entity without health")
+	}
+}
+
+func TestScoreAndLives(t *testing.T) {
+	manager := newTestManager()
+	g := &Game{lives: 2}
+	scene := &PlayScene{game: g, camera: manager.camera}
+	scene.manager = manager
+	scene.RegisterEventHandlers()
+	events := manager.GetEventBus()
+
+	start := manager.AddEntity("start", ENEMY_LAYER)
+	start.AddComponent(NewTransformComponent(Vec2{40, 50}, Vec2{}, 1, 1, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	enemy, _ := addHealth(manager, "enemy", 1, 50)
+	var health *HealthComponent
+	scene.player, health = addHealth(manager, "player", 2, 0)
+	transform := scene.player.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+
+	ApplyDamage(enemy, scene.player)
+	events.Flush()
+	if g.score != 50 || enemy.IsActive() {
+		t.Errorf("killing the enemy scored %d", g.score)
+	}
+
+	// A killed event without health scores nothing but doesn't panic
+	events.Publish(EntityKilledEvent{start, scene.player})
+	if g.score != 50 {
+		t.Errorf("score %d after a kill without health", g.score)
+	}
+
+	transform.position = Vec2{100, 100}
+	health.TakeDamage(2, enemy)
+	events.Flush()
+	if g.lives != 1 || health.Health() != 2 || !health.IsInvulnerable() || transform.position != (Vec2{40, 50}) {
+		t.Errorf("after dying: %d lives, %d health at %v", g.lives, health.Health(), transform.position)
+	}
+
+	health.invulnerable = 0
+	health.TakeDamage(2, enemy)
+	events.Flush()
+	if g.lives != 0 || len(g.sceneChanges) != 1 {
+		t.Errorf("losing the last life left %d lives and %d scene changes", g.lives, len(g.sceneChanges))
+	}
+}
@@ -0,0 +1,23 @@
+package engine
+
+import "testing"
+
+// newTestManager is an entity manager that draws nowhere.
+func newTestManager() *EntityManager {
+	return &EntityManager{camera: NewCamera(0, 0)}
+}
+
+// newHeadlessManager is an entity manager drawing into an ImageRenderer of
+// width by height pixels, with silent audio and a camera over the top left of
+// the world.
+func newHeadlessManager(t *testing.T, width, height int) (*ImageRenderer, *EntityManager) {
+	t.Helper()
+	renderer, err := NewImageRenderer(width, height, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetManager := NewAssetManager(renderer, NewNullAudioBackend())
+	manager := &EntityManager{renderer: renderer, input: NewInput(), camera: NewCamera(width, height), assetManager: assetManager,
+		collisions: NewDefaultCollisionMatrix(), events: NewEventBus()}
+	return renderer, manager
+}
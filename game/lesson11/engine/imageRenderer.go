@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type imageTexture struct {
+	image *image.RGBA
+}
+
+func (t *imageTexture) Size() (int32, int32) {
+	size := t.image.Bounds().Size()
+	return int32(size.X), int32(size.Y)
+}
+
+func (t *imageTexture) Destroy() {}
+
+// ImageRenderer draws in memory, without a window or GPU, so the game can run
+// headless. Each presented frame is kept for inspection and, given a
+// directory, written there as frame-00000.png, frame-00001.png and so on.
+type ImageRenderer struct {
+	frame     *image.RGBA
+	target    *image.RGBA
+	directory string
+	presented int
+}
+
+func NewImageRenderer(width, height int, directory string) (*ImageRenderer, error) {
+	if directory != "" {
+		if err := os.MkdirAll(directory, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create frame directory: %v", err)
+		}
+	}
+	frame := image.NewRGBA(image.Rect(0, 0, width, height))
+	return &ImageRenderer{frame: frame, target: frame, directory: directory}, nil
+}
+
+// Frame is the last presented frame.
+func (r *ImageRenderer) Frame() *image.RGBA {
+	return r.frame
+}
+
+// FrameCount is how many frames have been presented.
+func (r *ImageRenderer) FrameCount() int {
+	return r.presented
+}
+
+func (r *ImageRenderer) LoadTexture(filename string) (Texture, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoded, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", filename, err)
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	return &imageTexture{rgba}, nil
+}
+
+func (r *ImageRenderer) ReloadTexture(texture Texture, filename string) error {
+	loaded, err := r.LoadTexture(filename)
+	if err != nil {
+		return err
+	}
+	texture.(*imageTexture).image = loaded.(*imageTexture).image
+	return nil
+}
+
+func (r *ImageRenderer) CreateTexture(width, height int32, target bool) (Texture, error) {
+	return &imageTexture{image.NewRGBA(image.Rect(0, 0, int(width), int(height)))}, nil
+}
+
+func (r *ImageRenderer) UpdateTexture(texture Texture, rect sdl.Rect, pixels []byte, pitch int) error {
+	dst := texture.(*imageTexture).image
+	if !image.Rect(int(rect.X), int(rect.Y), int(rect.X+rect.W), int(rect.Y+rect.H)).In(dst.Bounds()) {
+		return fmt.Errorf("texture update %v is out of bounds", rect)
+	}
+	for y := range int(rect.H) {
+		for x := range int(rect.W) {
+			// ARGB8888 is stored B, G, R, A in memory
+			i := y*pitch + x*4
+			if i+3 >= len(pixels) {
+				return fmt.Errorf("texture update %v needs more pixels", rect)
+			}
+			dst.Set(int(rect.X)+x, int(rect.Y)+y, color.NRGBA{R: pixels[i+2], G: pixels[i+1], B: pixels[i], A: pixels[i+3]})
+		}
+	}
+	return nil
+}
+
+func (r *ImageRenderer) SetTarget(texture Texture) error {
+	if texture == nil {
+		r.target = r.frame
+	} else {
+		r.target = texture.(*imageTexture).image
+	}
+	return nil
+}
+
+func (r *ImageRenderer) DrawSprite(texture Texture, source, destination sdl.Rect, angle float64, pivot *sdl.Point, flip sdl.RendererFlip) {
+	r.blit(texture.(*imageTexture).image, source, destination, angle, pivot, flip, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+}
+
+func (r *ImageRenderer) DrawText(texture Texture, source, destination sdl.Rect, color sdl.Color) {
+	r.DrawTinted(texture, source, destination, color)
+}
+
+func (r *ImageRenderer) DrawTinted(texture Texture, source, destination sdl.Rect, color sdl.Color) {
+	r.blit(texture.(*imageTexture).image, source, destination, 0, nil, sdl.FLIP_NONE, color)
+}
+
+// blit scales source onto destination, turned angle degrees around pivot,
+// nearest neighbour like SDL's default scale quality, and blends it over the
+// target tinted by tint.
+func (r *ImageRenderer) blit(src *image.RGBA, source, destination sdl.Rect, angle float64, pivot *sdl.Point, flip sdl.RendererFlip, tint sdl.Color) {
+	if source.W <= 0 || source.H <= 0 || destination.W <= 0 || destination.H <= 0 {
+		return
+	}
+	center := Vec2{float64(destination.W) / 2, float64(destination.H) / 2}
+	if pivot != nil {
+		center = Vec2{float64(pivot.X), float64(pivot.Y)}
+	}
+	origin := Vec2{float64(destination.X), float64(destination.Y)}.Add(center)
+
+	// Every target pixel the turned rectangle covers is turned back to find
+	// where in destination it came from
+	low := Vec2{math.Inf(1), math.Inf(1)}
+	high := Vec2{math.Inf(-1), math.Inf(-1)}
+	for _, corner := range []Vec2{{0, 0}, {float64(destination.W), 0}, {0, float64(destination.H)}, {float64(destination.W), float64(destination.H)}} {
+		p := origin.Add(rotate(corner.Sub(center), angle))
+		low = Vec2{min(low.X(), p.X()), min(low.Y(), p.Y())}
+		high = Vec2{max(high.X(), p.X()), max(high.Y(), p.Y())}
+	}
+	area := image.Rect(int(math.Floor(low.X())), int(math.Floor(low.Y())), int(math.Ceil(high.X())), int(math.Ceil(high.Y())))
+	clip := area.Intersect(r.target.Bounds())
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			local := rotate(Vec2{float64(x) + 0.5, float64(y) + 0.5}.Sub(origin), -angle).Add(center)
+			u, v := int32(math.Floor(local.X())), int32(math.Floor(local.Y()))
+			if u < 0 || v < 0 || u >= destination.W || v >= destination.H {
+				continue
+			}
+			if flip&sdl.FLIP_HORIZONTAL != 0 {
+				u = destination.W - 1 - u
+			}
+			if flip&sdl.FLIP_VERTICAL != 0 {
+				v = destination.H - 1 - v
+			}
+			sx := int(source.X + u*source.W/destination.W)
+			sy := int(source.Y + v*source.H/destination.H)
+			if !(image.Point{sx, sy}.In(src.Bounds())) {
+				continue
+			}
+			i := src.PixOffset(sx, sy)
+			r.blend(x, y, modulate(src.Pix[i], tint.R, tint.A), modulate(src.Pix[i+1], tint.G, tint.A),
+				modulate(src.Pix[i+2], tint.B, tint.A), modulate(src.Pix[i+3], 255, tint.A))
+		}
+	}
+}
+
+func modulate(value, channel, alpha uint8) uint8 {
+	return uint8(uint32(value) * uint32(channel) / 255 * uint32(alpha) / 255)
+}
+
+// blend draws one premultiplied pixel over the target.
+func (r *ImageRenderer) blend(x, y int, red, green, blue, alpha uint8) {
+	i := r.target.PixOffset(x, y)
+	pix := r.target.Pix[i : i+4 : i+4]
+	inverse := 255 - uint32(alpha)
+	pix[0] = red + uint8(uint32(pix[0])*inverse/255)
+	pix[1] = green + uint8(uint32(pix[1])*inverse/255)
+	pix[2] = blue + uint8(uint32(pix[2])*inverse/255)
+	pix[3] = alpha + uint8(uint32(pix[3])*inverse/255)
+}
+
+func (r *ImageRenderer) FillRect(rect sdl.Rect, fill sdl.Color) {
+	clip := image.Rect(int(rect.X), int(rect.Y), int(rect.X+rect.W), int(rect.Y+rect.H)).Intersect(r.target.Bounds())
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			r.blend(x, y, modulate(fill.R, 255, fill.A), modulate(fill.G, 255, fill.A), modulate(fill.B, 255, fill.A), fill.A)
+		}
+	}
+}
+
+func (r *ImageRenderer) DrawRect(rect sdl.Rect, outline sdl.Color) {
+	if rect.W <= 0 || rect.H <= 0 {
+		return
+	}
+	r.FillRect(sdl.Rect{X: rect.X, Y: rect.Y, W: rect.W, H: 1}, outline)
+	if rect.H > 1 {
+		r.FillRect(sdl.Rect{X: rect.X, Y: rect.Y + rect.H - 1, W: rect.W, H: 1}, outline)
+	}
+	if rect.H > 2 {
+		r.FillRect(sdl.Rect{X: rect.X, Y: rect.Y + 1, W: 1, H: rect.H - 2}, outline)
+		if rect.W > 1 {
+			r.FillRect(sdl.Rect{X: rect.X + rect.W - 1, Y: rect.Y + 1, W: 1, H: rect.H - 2}, outline)
+		}
+	}
+}
+
+func (r *ImageRenderer) Clear(fill sdl.Color) {
+	draw.Draw(r.target, r.target.Bounds(), image.NewUniform(color.NRGBA{R: fill.R, G: fill.G, B: fill.B, A: fill.A}), image.Point{}, draw.Src)
+}
+
+func (r *ImageRenderer) Present() error {
+	defer func() { r.presented++ }()
+	if r.directory == "" {
+		return nil
+	}
+
+	file, err := os.Create(filepath.Join(r.directory, fmt.Sprintf("frame-%05d.png", r.presented)))
+	if err != nil {
+		return fmt.Errorf("failed to write frame: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, r.frame); err != nil {
+		return fmt.Errorf("failed to write frame: %v", err)
+	}
+	return nil
+}
+
+func (r *ImageRenderer) Destroy() {}
@@ -0,0 +1,307 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const NUM_MOUSE_BUTTONS = 8
+
+type inputBindingKind int
+
+const (
+	KEY_BINDING inputBindingKind = iota
+	MOUSE_BINDING
+	GAMEPAD_BINDING
+)
+
+type inputBinding struct {
+	kind   inputBindingKind
+	key    sdl.Keycode
+	button int
+}
+
+type buttonState struct {
+	down     bool
+	pressed  bool
+	released bool
+}
+
+func (s *buttonState) press() {
+	if !s.down {
+		s.pressed = true
+	}
+	s.down = true
+}
+
+func (s *buttonState) release() {
+	if s.down {
+		s.released = true
+	}
+	s.down = false
+}
+
+// Input is a snapshot of keyboard, mouse and gamepad state for one frame.
+// Every SDL event of the frame is folded in, so presses can't be dropped, and
+// named actions such as "move_up" resolve through their bindings.
+type Input struct {
+	keys         map[sdl.Keycode]*buttonState
+	mouseButtons [NUM_MOUSE_BUTTONS]buttonState
+	mouseX       int32
+	mouseY       int32
+	gamepad      map[sdl.GameControllerButton]*buttonState
+	axes         map[sdl.GameControllerAxis]float64
+	controllers  map[sdl.JoystickID]*sdl.GameController
+	actions      map[string][]inputBinding
+}
+
+func NewInput() *Input {
+	return &Input{
+		keys:        make(map[sdl.Keycode]*buttonState),
+		gamepad:     make(map[sdl.GameControllerButton]*buttonState),
+		axes:        make(map[sdl.GameControllerAxis]float64),
+		controllers: make(map[sdl.JoystickID]*sdl.GameController),
+		actions:     make(map[string][]inputBinding),
+	}
+}
+
+// BeginFrame forgets the pressed/released edges of the previous frame; held
+// buttons stay down.
+func (in *Input) BeginFrame() {
+	for _, state := range in.keys {
+		state.pressed, state.released = false, false
+	}
+	for i := range in.mouseButtons {
+		in.mouseButtons[i].pressed, in.mouseButtons[i].released = false, false
+	}
+	for _, state := range in.gamepad {
+		state.pressed, state.released = false, false
+	}
+}
+
+func (in *Input) HandleEvent(event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		state := in.keyState(t.Keysym.Sym)
+		if t.Type == sdl.KEYDOWN {
+			state.press()
+		} else {
+			state.release()
+		}
+	case *sdl.MouseMotionEvent:
+		in.mouseX, in.mouseY = t.X, t.Y
+	case *sdl.MouseButtonEvent:
+		in.mouseX, in.mouseY = t.X, t.Y
+		if int(t.Button) < NUM_MOUSE_BUTTONS {
+			if t.Type == sdl.MOUSEBUTTONDOWN {
+				in.mouseButtons[t.Button].press()
+			} else {
+				in.mouseButtons[t.Button].release()
+			}
+		}
+	case *sdl.ControllerDeviceEvent:
+		switch t.Type {
+		case sdl.CONTROLLERDEVICEADDED:
+			// For added devices Which is the device index, not the instance id
+			if controller := sdl.GameControllerOpen(int(t.Which)); controller != nil {
+				in.controllers[controller.Joystick().InstanceID()] = controller
+			}
+		case sdl.CONTROLLERDEVICEREMOVED:
+			if controller, ok := in.controllers[t.Which]; ok {
+				controller.Close()
+				delete(in.controllers, t.Which)
+			}
+		}
+	case *sdl.ControllerButtonEvent:
+		state := in.gamepadState(sdl.GameControllerButton(t.Button))
+		if t.Type == sdl.CONTROLLERBUTTONDOWN {
+			state.press()
+		} else {
+			state.release()
+		}
+	case *sdl.ControllerAxisEvent:
+		in.axes[sdl.GameControllerAxis(t.Axis)] = float64(t.Value) / 32767
+	}
+}
+
+func (in *Input) Close() {
+	for id, controller := range in.controllers {
+		controller.Close()
+		delete(in.controllers, id)
+	}
+}
+
+func (in *Input) keyState(key sdl.Keycode) *buttonState {
+	state, ok := in.keys[key]
+	if !ok {
+		state = &buttonState{}
+		in.keys[key] = state
+	}
+	return state
+}
+
+func (in *Input) gamepadState(button sdl.GameControllerButton) *buttonState {
+	state, ok := in.gamepad[button]
+	if !ok {
+		state = &buttonState{}
+		in.gamepad[button] = state
+	}
+	return state
+}
+
+func (in *Input) KeyDown(key sdl.Keycode) bool {
+	return in.keyState(key).down
+}
+
+func (in *Input) KeyPressed(key sdl.Keycode) bool {
+	return in.keyState(key).pressed
+}
+
+func (in *Input) KeyReleased(key sdl.Keycode) bool {
+	return in.keyState(key).released
+}
+
+func (in *Input) MouseDown(button int) bool {
+	return button >= 0 && button < NUM_MOUSE_BUTTONS && in.mouseButtons[button].down
+}
+
+func (in *Input) MousePressed(button int) bool {
+	return button >= 0 && button < NUM_MOUSE_BUTTONS && in.mouseButtons[button].pressed
+}
+
+func (in *Input) MousePosition() (int32, int32) {
+	return in.mouseX, in.mouseY
+}
+
+func (in *Input) GamepadDown(button sdl.GameControllerButton) bool {
+	return in.gamepadState(button).down
+}
+
+func (in *Input) GamepadAxis(axis sdl.GameControllerAxis) float64 {
+	return in.axes[axis]
+}
+
+// Bind adds bindings to an action. A binding is a key name as SDL spells it
+// ("w", "space", "up"), "mouse:left|middle|right" or "gamepad:<button>" using
+// SDL GameController button names ("a", "dpup", "start").
+func (in *Input) Bind(action string, bindings ...string) error {
+	for _, name := range bindings {
+		if name == "" {
+			continue
+		}
+		binding, err := parseInputBinding(name)
+		if err != nil {
+			return fmt.Errorf("failed to bind %s: %v", action, err)
+		}
+		in.actions[action] = append(in.actions[action], binding)
+	}
+	return nil
+}
+
+func (in *Input) Unbind(action string) {
+	delete(in.actions, action)
+}
+
+func parseInputBinding(name string) (inputBinding, error) {
+	device, button, found := strings.Cut(strings.ToLower(name), ":")
+	if !found {
+		key := sdl.GetKeyFromName(name)
+		if key == sdl.K_UNKNOWN {
+			return inputBinding{}, fmt.Errorf("unknown key %q", name)
+		}
+		return inputBinding{kind: KEY_BINDING, key: key}, nil
+	}
+
+	switch device {
+	case "mouse":
+		switch button {
+		case "left":
+			return inputBinding{kind: MOUSE_BINDING, button: sdl.BUTTON_LEFT}, nil
+		case "middle":
+			return inputBinding{kind: MOUSE_BINDING, button: sdl.BUTTON_MIDDLE}, nil
+		case "right":
+			return inputBinding{kind: MOUSE_BINDING, button: sdl.BUTTON_RIGHT}, nil
+		}
+	case "gamepad":
+		if gamepadButton := sdl.GameControllerGetButtonFromString(button); gamepadButton != sdl.CONTROLLER_BUTTON_INVALID {
+			return inputBinding{kind: GAMEPAD_BINDING, button: int(gamepadButton)}, nil
+		}
+	}
+	return inputBinding{}, fmt.Errorf("unknown input %q", name)
+}
+
+func (in *Input) bindingState(binding inputBinding) buttonState {
+	switch binding.kind {
+	case MOUSE_BINDING:
+		if binding.button < NUM_MOUSE_BUTTONS {
+			return in.mouseButtons[binding.button]
+		}
+	case GAMEPAD_BINDING:
+		return *in.gamepadState(sdl.GameControllerButton(binding.button))
+	default:
+		return *in.keyState(binding.key)
+	}
+	return buttonState{}
+}
+
+func (in *Input) ActionDown(action string) bool {
+	for _, binding := range in.actions[action] {
+		if in.bindingState(binding).down {
+			return true
+		}
+	}
+	return false
+}
+
+func (in *Input) ActionPressed(action string) bool {
+	for _, binding := range in.actions[action] {
+		if in.bindingState(binding).pressed {
+			return true
+		}
+	}
+	return false
+}
+
+func (in *Input) ActionReleased(action string) bool {
+	for _, binding := range in.actions[action] {
+		if in.bindingState(binding).released {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadBindings runs a Lua config script declaring an Input table whose actions
+// map each action name to a list of bindings.
+func (in *Input) LoadBindings(filename string) error {
+	L := lua.NewState()
+	defer L.Close()
+
+	if err := L.DoFile(filename); err != nil {
+		return fmt.Errorf("failed to run input config: %v", err)
+	}
+
+	config, ok := L.GetGlobal("Input").(*lua.LTable)
+	if !ok {
+		return fmt.Errorf("input config does not define an Input table")
+	}
+
+	var err error
+	if actions := luaTable(config, "actions"); actions != nil {
+		actions.ForEach(func(key, value lua.LValue) {
+			bindings, ok := value.(*lua.LTable)
+			if !ok || err != nil {
+				return
+			}
+			bindings.ForEach(func(_, binding lua.LValue) {
+				if err == nil {
+					err = in.Bind(lua.LVAsString(key), lua.LVAsString(binding))
+				}
+			})
+		})
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestParseInputBinding(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		binding inputBinding
+	}{
+		{"w", inputBinding{kind: KEY_BINDING, key: sdl.K_w}},
+		{"space", inputBinding{kind: KEY_BINDING, key: sdl.K_SPACE}},
+		{"Up", inputBinding{kind: KEY_BINDING, key: sdl.K_UP}},
+		{"mouse:left", inputBinding{kind: MOUSE_BINDING, button: sdl.BUTTON_LEFT}},
+		{"MOUSE:Right", inputBinding{kind: MOUSE_BINDING, button: sdl.BUTTON_RIGHT}},
+		{"gamepad:a", inputBinding{kind: GAMEPAD_BINDING, button: sdl.CONTROLLER_BUTTON_A}},
+		{"gamepad:dpup", inputBinding{kind: GAMEPAD_BINDING, button: sdl.CONTROLLER_BUTTON_DPAD_UP}},
+	} {
+		binding, err := parseInputBinding(test.name)
+		if err != nil || binding != test.binding {
+			t.Errorf("%q parsed as %+v, %v, want %+v", test.name, binding, err, test.binding)
+		}
+	}
+
+	for _, name := range []string{"notakey", "mouse:side", "gamepad:nope", "joystick:a"} {
+		if binding, err := parseInputBinding(name); err == nil {
+			t.Errorf("%q parsed as %+v, want an error", name, binding)
+		}
+	}
+}
+
+func TestLoadBindings(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "Input.lua")
+	writeBindings := func(script string) {
+		t.Helper()
+		if err := os.WriteFile(filename, []byte(script), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeBindings(`Input = { actions = { shoot = { "space", "mouse:left", "gamepad:a" } } }`)
+	input := NewInput()
+	if err := input.LoadBindings(filename); err != nil {
+		t.Fatal(err)
+	}
+	if bindings := input.actions[SHOOT_ACTION]; len(bindings) != 3 || bindings[1].kind != MOUSE_BINDING || bindings[2].kind != GAMEPAD_BINDING {
+		t.Errorf("shoot bound to %+v", bindings)
+	}
+
+	input.HandleEvent(&sdl.MouseButtonEvent{Type: sdl.MOUSEBUTTONDOWN, Button: sdl.BUTTON_LEFT})
+	if !input.ActionPressed(SHOOT_ACTION) || !input.ActionDown(SHOOT_ACTION) {
+		t.Error("clicking didn't shoot")
+	}
+
+	for _, script := range []string{
+		`Input = { actions = { shoot = { "mouse:side" } } }`,
+		`Input = { actions = { shoot = { "gamepad:nope" } } }`,
+		`Actions = {}`,
+		`Input = {`,
+	} {
+		writeBindings(script)
+		if err := NewInput().LoadBindings(filename); err == nil {
+			t.Errorf("loaded %s", script)
+		}
+	}
+}
+
+func TestInputEdges(t *testing.T) {
+	input := NewInput()
+	if err := input.Bind(MOVE_UP_ACTION, "w"); err != nil {
+		t.Fatal(err)
+	}
+	key := func(typ uint32) {
+		input.HandleEvent(&sdl.KeyboardEvent{Type: typ, Keysym: sdl.Keysym{Sym: sdl.K_w}})
+	}
+
+	// Tapped within one frame: both edges are seen, the key isn't held
+	input.BeginFrame()
+	key(sdl.KEYDOWN)
+	key(sdl.KEYUP)
+	if !input.ActionPressed(MOVE_UP_ACTION) || !input.ActionReleased(MOVE_UP_ACTION) || input.ActionDown(MOVE_UP_ACTION) {
+		t.Error("a tap within one frame was missed")
+	}
+
+	input.BeginFrame()
+	if input.ActionPressed(MOVE_UP_ACTION) || input.ActionReleased(MOVE_UP_ACTION) {
+		t.Error("the tap's edges outlived their frame")
+	}
+
+	// Key repeat doesn't press again
+	key(sdl.KEYDOWN)
+	input.BeginFrame()
+	key(sdl.KEYDOWN)
+	if input.ActionPressed(MOVE_UP_ACTION) || !input.ActionDown(MOVE_UP_ACTION) {
+		t.Error("a held key was pressed again")
+	}
+}
+
+func TestKeyboardControlBadKey(t *testing.T) {
+	manager := newTestManager()
+	entity := manager.AddEntity("player", ENEMY_LAYER)
+	entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 1, 1, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	control := entity.AddComponent(NewKeyboardControlComponent("w", "notakey", "", "", ""), KEYBOARD_CONTROL_COMPONENT).(*KeyboardControlComponent)
+	if control.Err() == nil {
+		t.Error("binding an unknown key didn't fail")
+	}
+	if len(control.bindings[MOVE_UP_ACTION]) != 1 {
+		t.Errorf("up bound to %v, want w", control.bindings[MOVE_UP_ACTION])
+	}
+}
@@ -0,0 +1,695 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+	lua "github.com/yuin/gopher-lua"
+)
+
+type LevelLoader struct {
+	manager      *EntityManager
+	assetManager *AssetManager
+	mapFile      string
+}
+
+func LevelScript(levelNumber int) string {
+	return filepath.Join(rootpath, "assets/scripts", fmt.Sprintf("Level%d.lua", levelNumber))
+}
+
+func runLevelScript(L *lua.LState, levelNumber int) (*lua.LTable, error) {
+	levelName := fmt.Sprintf("Level%d", levelNumber)
+	if err := L.DoFile(LevelScript(levelNumber)); err != nil {
+		return nil, fmt.Errorf("failed to run level script %s: %v", levelName, err)
+	}
+
+	levelData, ok := L.GetGlobal(levelName).(*lua.LTable)
+	if !ok {
+		return nil, fmt.Errorf("level script does not define a %s table", levelName)
+	}
+	return levelData, nil
+}
+
+func (l *LevelLoader) LoadLevel(levelNumber int) error {
+	L := lua.NewState()
+	defer L.Close()
+
+	levelData, err := runLevelScript(L, levelNumber)
+	if err != nil {
+		return err
+	}
+
+	if err := l.loadAssets(luaTable(levelData, "assets")); err != nil {
+		return err
+	}
+
+	if err := l.loadMap(luaTable(levelData, "map")); err != nil {
+		return err
+	}
+
+	if err := l.loadCollisions(luaTable(levelData, "collisions")); err != nil {
+		return err
+	}
+
+	if err := l.loadEntities(luaTable(levelData, "entities")); err != nil {
+		return err
+	}
+
+	if err := l.loadMusic(luaTable(levelData, "music")); err != nil {
+		return err
+	}
+
+	return l.loadCamera(luaTable(levelData, "camera"))
+}
+
+// loadMusic starts the level's music, a music asset played loops more times
+// after the first, or forever with -1.
+func (l *LevelLoader) loadMusic(music *lua.LTable) error {
+	if music == nil {
+		return nil
+	}
+	musicId := luaString(music, "musicAssetId", "")
+	track := l.assetManager.GetMusic(musicId)
+	if track == nil {
+		return fmt.Errorf("level plays unknown music %q", musicId)
+	}
+	if audio := l.assetManager.GetAudio(); audio != nil {
+		return audio.PlayMusic(track, luaInt(music, "loops", -1))
+	}
+	return nil
+}
+
+// ReloadMap builds the level's tilemap again from its map file, for when only
+// that file changed. The map's texture must still be loaded.
+func (l *LevelLoader) ReloadMap(levelNumber int) error {
+	L := lua.NewState()
+	defer L.Close()
+
+	levelData, err := runLevelScript(L, levelNumber)
+	if err != nil {
+		return err
+	}
+	return l.loadMap(luaTable(levelData, "map"))
+}
+
+// MapFile is the map file the level loaded, if any.
+func (l *LevelLoader) MapFile() string {
+	return l.mapFile
+}
+
+func (l *LevelLoader) loadAssets(assets *lua.LTable) error {
+	return forEachIndexed(assets, func(asset *lua.LTable) error {
+		assetType := luaString(asset, "type", "")
+		assetId := luaString(asset, "id", "")
+		assetFile := filepath.Join(rootpath, luaString(asset, "file", ""))
+
+		switch assetType {
+		case "texture":
+			if err := l.assetManager.AddTexture(assetId, assetFile); err != nil {
+				return err
+			}
+		case "sound":
+			if err := l.assetManager.AddSound(assetId, assetFile); err != nil {
+				return err
+			}
+		case "music":
+			if err := l.assetManager.AddMusic(assetId, assetFile); err != nil {
+				return err
+			}
+		case "animation":
+			if err := l.assetManager.AddAnimations(assetId, assetFile); err != nil {
+				return err
+			}
+		case "font":
+			if err := l.assetManager.AddFont(assetId, assetFile, luaInt(asset, "fontSize", 14)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (l *LevelLoader) loadMap(mapData *lua.LTable) error {
+	if mapData == nil {
+		return nil
+	}
+
+	mapFile := filepath.Join(rootpath, luaString(mapData, "file", ""))
+	l.mapFile = mapFile
+	switch strings.ToLower(filepath.Ext(mapFile)) {
+	case ".tmx", ".tmj":
+		if _, err := NewTiledImporter(l.manager, l.assetManager, luaInt(mapData, "scale", 1)).Import(mapFile); err != nil {
+			return err
+		}
+	default:
+		textureId := luaString(mapData, "textureAssetId", "")
+		texture := l.assetManager.GetTexture(textureId)
+		if texture == nil {
+			return fmt.Errorf("map texture %q is not loaded", textureId)
+		}
+
+		m := Map{manager: l.manager, texture: texture, scale: luaInt(mapData, "scale", 1), titleSize: luaInt(mapData, "tileSize", 32)}
+		if err := m.LoadMap(mapFile, luaInt(mapData, "mapSizeX", 0), luaInt(mapData, "mapSizeY", 0)); err != nil {
+			return err
+		}
+	}
+	return l.loadWalkability(luaTable(mapData, "walkability"))
+}
+
+// loadWalkability marks the cells of the TILEMAP_LAYER tilemap holding the
+// tile ids listed as blocked, or costing more to cross, and gives the level a
+// path finder over it. Ids are written as in the map file, "21" for a .map
+// tile and the global id for a Tiled one.
+func (l *LevelLoader) loadWalkability(walkability *lua.LTable) error {
+	navigation := l.manager.BaseTilemap()
+	if navigation == nil {
+		l.manager.SetPathFinder(nil)
+		return nil
+	}
+
+	blocked := make(map[int]bool)
+	costs := make(map[int]float64)
+	var err error
+	if list := luaTable(walkability, "blocked"); list != nil {
+		list.ForEach(func(_, value lua.LValue) {
+			id, parseErr := luaTileId(value)
+			if parseErr != nil {
+				err = parseErr
+			}
+			blocked[id] = true
+		})
+	}
+	if table := luaTable(walkability, "costs"); table != nil {
+		table.ForEach(func(key, value lua.LValue) {
+			id, parseErr := luaTileId(key)
+			cost, ok := value.(lua.LNumber)
+			if parseErr != nil || !ok {
+				err = fmt.Errorf("invalid tile cost %v = %v", key, value)
+			}
+			costs[id] = float64(cost)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	width, height := navigation.Size()
+	for y := range height {
+		for x := range width {
+			for _, id := range navigation.TileIds(x, y) {
+				if blocked[id] {
+					navigation.SetWalkable(x, y, false)
+				}
+				if cost, ok := costs[id]; ok {
+					navigation.SetCost(x, y, max(cost, navigation.Cost(x, y)))
+				}
+			}
+		}
+	}
+	l.manager.SetPathFinder(NewPathFinder(navigation))
+	return nil
+}
+
+// luaTileId reads a tile id given as a number or, to keep leading zeros
+// readable, a string.
+func luaTileId(value lua.LValue) (int, error) {
+	switch v := value.(type) {
+	case lua.LNumber:
+		return int(v), nil
+	case lua.LString:
+		if id, err := strconv.Atoi(string(v)); err == nil {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid tile id %v", value)
+}
+
+func (l *LevelLoader) loadCamera(cameraData *lua.LTable) error {
+	camera := l.manager.GetCamera()
+	if camera == nil {
+		return nil
+	}
+
+	camera.SetBounds(l.manager.MapSize())
+	camera.SetZoom(luaFloat(cameraData, "zoom", 1))
+	camera.SetSmoothTime(luaFloat(cameraData, "smoothTime", CAMERA_SMOOTH_TIME))
+	deadZone := luaTable(cameraData, "deadZone")
+	camera.SetDeadZone(luaInt(deadZone, "width", 0), luaInt(deadZone, "height", 0))
+
+	targetName := luaString(cameraData, "follow", "player")
+	target := l.manager.GetEntityByName(targetName)
+	if target == nil {
+		return fmt.Errorf("camera follows unknown entity %q", targetName)
+	}
+	camera.Follow(target)
+	return nil
+}
+
+func (l *LevelLoader) loadCollisions(collisionsData *lua.LTable) error {
+	if collisionsData == nil {
+		return nil
+	}
+
+	collisions := l.manager.GetCollisionMatrix()
+	collisions.ClearRules()
+
+	var err error
+	if masks := luaTable(collisionsData, "masks"); masks != nil {
+		masks.ForEach(func(key, value lua.LValue) {
+			tag, ok := key.(lua.LString)
+			targets, isTable := value.(*lua.LTable)
+			if !ok || !isTable || err != nil {
+				return
+			}
+			targets.ForEach(func(_, target lua.LValue) {
+				if err == nil {
+					err = collisions.SetMask(string(tag), lua.LVAsString(target))
+				}
+			})
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return forEachIndexed(luaTable(collisionsData, "rules"), func(rule *lua.LTable) error {
+		thisTag := luaString(rule, "this", "")
+		thatTag := luaString(rule, "that", "")
+		if thisTag == "" || thatTag == "" {
+			return fmt.Errorf("collision rule needs both this and that tags")
+		}
+		return collisions.AddRule(thisTag, thatTag, collisions.CollisionType(luaString(rule, "type", "NO_COLLISION")))
+	})
+}
+
+func (l *LevelLoader) loadEntities(entities *lua.LTable) error {
+	return forEachIndexed(entities, func(entityData *lua.LTable) error {
+		layer := luaInt(entityData, "layer", 0)
+		if layer < 0 || layer >= NUM_LAYERS {
+			return fmt.Errorf("entity %s has invalid layer %d", luaString(entityData, "name", ""), layer)
+		}
+
+		entity := l.manager.AddEntity(luaString(entityData, "name", ""), LayerType(layer))
+		// A child's transform is relative to its parent's, so the parent has to
+		// come first in the level
+		if parentName := luaString(entityData, "parent", ""); parentName != "" {
+			parent := l.manager.GetEntityByName(parentName)
+			if parent == nil {
+				return fmt.Errorf("entity %s has unknown parent %q, which must be defined before it", entity.name, parentName)
+			}
+			if err := entity.SetParent(parent); err != nil {
+				return err
+			}
+		}
+
+		components := luaTable(entityData, "components")
+		if components == nil {
+			return nil
+		}
+
+		transform := luaTable(components, "transform")
+		for _, name := range []string{"sprite", "input"} {
+			if transform == nil && luaTable(components, name) != nil {
+				return fmt.Errorf("entity %s has a %s but no transform", entity.name, name)
+			}
+		}
+		if transform != nil {
+			position := luaTable(transform, "position")
+			velocity := luaTable(transform, "velocity")
+			transformComponent := NewTransformComponent(
+				Vec2{luaFloat(position, "x", 0), luaFloat(position, "y", 0)},
+				Vec2{luaFloat(velocity, "x", 0), luaFloat(velocity, "y", 0)},
+				luaInt(transform, "width", 0), luaInt(transform, "height", 0), luaVec2(transform, "scale", Vec2{1, 1}))
+			transformComponent.rotation = luaFloat(transform, "rotation", 0)
+			transformComponent.pivot = luaVec2(transform, "pivot", Vec2{0.5, 0.5})
+			if luaBool(transform, "flipX", false) {
+				transformComponent.flip |= sdl.FLIP_HORIZONTAL
+			}
+			if luaBool(transform, "flipY", false) {
+				transformComponent.flip |= sdl.FLIP_VERTICAL
+			}
+			if luaBool(transform, "faceVelocity", false) {
+				transformComponent.FaceVelocity(luaFloat(transform, "facing", 0))
+			}
+			entity.AddComponent(transformComponent, TRANSFORM_COMPONENT)
+		}
+
+		if sprite := luaTable(components, "sprite"); sprite != nil {
+			textureId := luaString(sprite, "textureAssetId", "")
+			texture := l.assetManager.GetTexture(textureId)
+			if texture == nil {
+				return fmt.Errorf("entity %s uses unknown texture %q", entity.name, textureId)
+			}
+			if animationsId := luaString(sprite, "animationAssetId", ""); animationsId != "" {
+				animations := l.assetManager.GetAnimations(animationsId)
+				if animations == nil {
+					return fmt.Errorf("entity %s uses unknown animations %q", entity.name, animationsId)
+				}
+				spriteComponent := NewAnimatedSpriteComponent(texture, animations, luaBool(sprite, "fixed", false))
+				entity.AddComponent(spriteComponent, SPRITE_COMPONENT)
+				if clip := luaString(sprite, "clip", ""); clip != "" {
+					spriteComponent.Play(clip)
+				}
+			} else if luaBool(sprite, "animated", false) {
+				entity.AddComponent(NewSpriteComponent2(texture, luaInt(sprite, "frameCount", 1), luaInt(sprite, "animationSpeed", 1),
+					luaBool(sprite, "hasDirections", false), luaBool(sprite, "fixed", false)), SPRITE_COMPONENT)
+			} else {
+				entity.AddComponent(NewSpriteComponent(texture), SPRITE_COMPONENT)
+			}
+		}
+
+		if collider := luaTable(components, "collider"); collider != nil && transform != nil {
+			position := luaTable(transform, "position")
+			scale := luaVec2(transform, "scale", Vec2{1, 1})
+			colliderComponent := NewColliderComponent(luaString(collider, "tag", ""),
+				luaInt(position, "x", 0), luaInt(position, "y", 0),
+				int(float64(luaInt(transform, "width", 0))*scale.X()), int(float64(luaInt(transform, "height", 0))*scale.Y()))
+			colliderComponent.SetOriented(luaBool(collider, "oriented", false))
+			entity.AddComponent(colliderComponent, COLLIDER_COMPONENT)
+		}
+
+		// Added before input so the keyboard control can find the weapon to fire
+		if weapon := luaTable(components, "weapon"); weapon != nil && transform != nil {
+			textureId := luaString(weapon, "textureAssetId", "")
+			texture := l.assetManager.GetTexture(textureId)
+			if texture == nil {
+				return fmt.Errorf("weapon of %s uses unknown texture %q", entity.name, textureId)
+			}
+			pool := NewProjectilePool(l.manager, texture, luaInt(weapon, "width", 4), luaInt(weapon, "height", 4),
+				luaString(weapon, "tag", "FRIENDLY_PROJECTILE"), luaInt(weapon, "damage", DEFAULT_DAMAGE), luaInt(weapon, "poolSize", 16))
+			entity.AddComponent(NewWeaponComponent(pool, luaFloat(weapon, "speed", 300), luaFloat(weapon, "range", 300),
+				luaFloat(weapon, "fireRate", 4)), WEAPON_COMPONENT)
+		}
+
+		if keyboard := luaTable(luaTable(components, "input"), "keyboard"); keyboard != nil {
+			control := entity.AddComponent(NewKeyboardControlComponent(luaString(keyboard, "up", ""), luaString(keyboard, "right", ""),
+				luaString(keyboard, "down", ""), luaString(keyboard, "left", ""), luaString(keyboard, "shoot", "")), KEYBOARD_CONTROL_COMPONENT)
+			if err := control.(*KeyboardControlComponent).Err(); err != nil {
+				return fmt.Errorf("entity %s: %v", entity.name, err)
+			}
+		}
+
+		if label := luaTable(components, "textLabel"); label != nil {
+			fontId := luaString(label, "fontAssetId", "")
+			if l.assetManager.GetFont(fontId) == nil {
+				return fmt.Errorf("entity %s uses unknown font %q", entity.name, fontId)
+			}
+			align, err := ParseTextAlign(luaString(label, "align", "left"))
+			if err != nil {
+				return fmt.Errorf("entity %s: %v", entity.name, err)
+			}
+
+			color := luaTable(label, "color")
+			textLabel := NewTextLabelComponent(luaInt(label, "x", 0), luaInt(label, "y", 0), luaString(label, "text", ""),
+				fontId, sdl.Color{
+					R: uint8(luaInt(color, "r", 255)),
+					G: uint8(luaInt(color, "g", 255)),
+					B: uint8(luaInt(color, "b", 255)),
+					A: uint8(luaInt(color, "a", 255)),
+				})
+			textLabel.align = align
+			textLabel.wrapWidth = int32(luaInt(label, "wrapWidth", 0))
+			entity.AddComponent(textLabel, TEXT_LABEL_COMPONENT)
+			if err := textLabel.Err(); err != nil {
+				return fmt.Errorf("entity %s: %v", entity.name, err)
+			}
+		}
+
+		if sound := luaTable(components, "soundEmitter"); sound != nil {
+			mode, err := ParseSoundMode(luaString(sound, "mode", "loop"))
+			if err != nil {
+				return fmt.Errorf("entity %s: %v", entity.name, err)
+			}
+			trigger, ok := EventTypeFromName(luaString(sound, "trigger", "COLLISION_ENTER"))
+			if !ok {
+				return fmt.Errorf("entity %s has an unknown sound trigger %q", entity.name, luaString(sound, "trigger", ""))
+			}
+			entity.AddComponent(NewSoundEmitterComponent(luaString(sound, "soundAssetId", ""), mode, trigger,
+				luaFloat(sound, "volume", 1), luaFloat(sound, "range", 0)), SOUND_EMITTER_COMPONENT)
+		}
+
+		if health := luaTable(components, "health"); health != nil {
+			entity.AddComponent(NewHealthComponent(luaInt(health, "health", 1), luaFloat(health, "invulnerability", 0),
+				luaInt(health, "score", 0)), HEALTH_COMPONENT)
+		}
+
+		if damage := luaTable(components, "damage"); damage != nil {
+			entity.AddComponent(NewDamageComponent(luaInt(damage, "amount", DEFAULT_DAMAGE)), DAMAGE_COMPONENT)
+		}
+
+		if minimap := luaTable(components, "minimap"); minimap != nil && transform != nil {
+			blips := make(map[string]sdl.Color)
+			if colors := luaTable(minimap, "blips"); colors != nil {
+				colors.ForEach(func(tag, value lua.LValue) {
+					color, _ := value.(*lua.LTable)
+					blips[tag.String()] = sdl.Color{
+						R: uint8(luaInt(color, "r", 255)),
+						G: uint8(luaInt(color, "g", 255)),
+						B: uint8(luaInt(color, "b", 255)),
+						A: uint8(luaInt(color, "a", 255)),
+					}
+				})
+			}
+			entity.AddComponent(NewMinimapComponent(luaString(minimap, "target", "player"), luaFloat(minimap, "range", 600),
+				luaBool(minimap, "square", false), blips), MINIMAP_COMPONENT)
+		}
+
+		if emitters := luaTable(components, "particleEmitters"); emitters != nil && transform != nil {
+			var particleEmitters []*ParticleEmitter
+			if err := forEachIndexed(emitters, func(emitterData *lua.LTable) error {
+				emitter, err := l.particleEmitter(emitterData)
+				if err != nil {
+					return fmt.Errorf("entity %s: %v", entity.name, err)
+				}
+				particleEmitters = append(particleEmitters, emitter)
+				return nil
+			}); err != nil {
+				return err
+			}
+			entity.AddComponent(NewParticleEmitterComponent(particleEmitters...), PARTICLE_EMITTER_COMPONENT)
+		}
+
+		// Added last so the behaviours can find the weapon and health they use
+		if ai := luaTable(components, "ai"); ai != nil && transform != nil {
+			behaviours, err := aiBehaviours(ai)
+			if err != nil {
+				return fmt.Errorf("entity %s: %v", entity.name, err)
+			}
+			if luaTable(ai, "turret") != nil && !entity.HasComponent(WEAPON_COMPONENT) {
+				return fmt.Errorf("entity %s has a turret but no weapon to aim", entity.name)
+			}
+			if luaTable(ai, "turret") != nil && luaTable(components, "projectileEmitter") != nil {
+				return fmt.Errorf("entity %s has a turret, which can't aim its projectileEmitter; give it a weapon instead", entity.name)
+			}
+			entity.AddComponent(NewAIComponent(luaString(ai, "target", "player"), behaviours...), AI_COMPONENT)
+		}
+
+		if emitter := luaTable(components, "projectileEmitter"); emitter != nil && transform != nil {
+			return l.addProjectile(entity, transform, emitter)
+		}
+		return nil
+	})
+}
+
+func (l *LevelLoader) addProjectile(parent *Entity, transform, emitter *lua.LTable) error {
+	position := luaTable(transform, "position")
+	width := luaInt(emitter, "width", 4)
+	height := luaInt(emitter, "height", 4)
+	x := luaInt(position, "x", 0) + luaInt(transform, "width", 0)/2
+	y := luaInt(position, "y", 0) + luaInt(transform, "height", 0)/2
+
+	textureId := luaString(emitter, "textureAssetId", "")
+	texture := l.assetManager.GetTexture(textureId)
+	if texture == nil {
+		return fmt.Errorf("projectile of %s uses unknown texture %q", parent.name, textureId)
+	}
+
+	projectile := l.manager.AddEntity("projectile", PROJECTILE_LAYER)
+	projectile.AddComponent(NewTransformComponent(Vec2{float64(x), float64(y)}, Vec2{0, 0}, width, height, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	projectile.AddComponent(NewSpriteComponent(texture), SPRITE_COMPONENT)
+	projectile.AddComponent(NewColliderComponent("PROJECTILE", x, y, width, height), COLLIDER_COMPONENT)
+	projectile.AddComponent(NewProjectileEmitterComponent(luaInt(emitter, "speed", 0), luaInt(emitter, "angle", 0),
+		luaInt(emitter, "range", 0), luaBool(emitter, "shouldLoop", false)), PROJECTILE_EMITTER_COMPONENT)
+	projectile.AddComponent(NewDamageComponent(luaInt(emitter, "damage", DEFAULT_DAMAGE)), DAMAGE_COMPONENT)
+
+	// The projectile is a separate entity, so it has to go when its shooter does
+	events := l.manager.GetEventBus()
+	var subscription SubscriptionId
+	subscription = Subscribe(events, func(e EntityDestroyedEvent) {
+		if e.entity == parent {
+			projectile.Destroy()
+			events.Unsubscribe(subscription)
+		}
+	})
+	return nil
+}
+
+// particleEmitter reads one entry of a particleEmitters block. Angles are in
+// degrees, spread being the width of the cone particles leave in.
+func (l *LevelLoader) particleEmitter(emitter *lua.LTable) (*ParticleEmitter, error) {
+	mode, err := ParseParticleMode(luaString(emitter, "mode", "burst"))
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := luaTable(emitter, "lifetime")
+	speed := luaTable(emitter, "speed")
+	gravity := luaTable(emitter, "gravity")
+	style := &ParticleStyle{
+		minLifetime: luaFloat(lifetime, "min", 0.5),
+		maxLifetime: luaFloat(lifetime, "max", 1),
+		minSpeed:    luaFloat(speed, "min", 20),
+		maxSpeed:    luaFloat(speed, "max", 60),
+		angle:       Radians(luaFloat(emitter, "angle", 0)),
+		spread:      Radians(luaFloat(emitter, "spread", 360)),
+		gravity:     Vec2{luaFloat(gravity, "x", 0), luaFloat(gravity, "y", 0)},
+	}
+	if style.minLifetime <= 0 || style.maxLifetime < style.minLifetime {
+		return nil, fmt.Errorf("invalid particle lifetime %v to %v", style.minLifetime, style.maxLifetime)
+	}
+
+	if textureId := luaString(emitter, "textureAssetId", ""); textureId != "" {
+		style.texture = l.assetManager.GetTexture(textureId)
+		if style.texture == nil {
+			return nil, fmt.Errorf("particles use unknown texture %q", textureId)
+		}
+		width, height := style.texture.Size()
+		style.frameCount = max(luaInt(emitter, "frameCount", 1), 1)
+		style.frameWidth = int32(luaInt(emitter, "frameWidth", int(width)/style.frameCount))
+		style.frameHeight = int32(luaInt(emitter, "frameHeight", int(height)))
+	}
+
+	if sizes := luaTable(emitter, "sizes"); sizes != nil {
+		for i := 0; ; i++ {
+			size, ok := sizes.RawGetInt(i).(lua.LNumber)
+			if !ok {
+				break
+			}
+			style.sizes = append(style.sizes, float64(size))
+		}
+	}
+	forEachIndexed(luaTable(emitter, "colors"), func(color *lua.LTable) error {
+		style.colors = append(style.colors, sdl.Color{
+			R: uint8(luaInt(color, "r", 255)),
+			G: uint8(luaInt(color, "g", 255)),
+			B: uint8(luaInt(color, "b", 255)),
+			A: uint8(luaInt(color, "a", 255)),
+		})
+		return nil
+	})
+
+	offset := luaTable(emitter, "offset")
+	particleEmitter := NewParticleEmitter(style, mode, luaInt(emitter, "count", 20), luaFloat(emitter, "rate", 20),
+		luaFloat(emitter, "duration", 0), Vec2{luaFloat(offset, "x", 0), luaFloat(offset, "y", 0)})
+	if triggerName := luaString(emitter, "trigger", ""); triggerName != "" {
+		trigger, ok := EventTypeFromName(triggerName)
+		if !ok {
+			return nil, fmt.Errorf("unknown particle trigger %q", triggerName)
+		}
+		particleEmitter.SetTrigger(trigger)
+	}
+	return particleEmitter, nil
+}
+
+// aiBehaviours reads an ai block in priority order: fleeing wins over
+// chasing, chasing over patrolling, and the turret aims whatever the entity
+// is doing.
+func aiBehaviours(ai *lua.LTable) ([]Behaviour, error) {
+	var behaviours []Behaviour
+	if flee := luaTable(ai, "flee"); flee != nil {
+		behaviours = append(behaviours, NewFleeBehaviour(luaFloat(flee, "health", 0.5), luaFloat(flee, "radius", 0),
+			luaFloat(flee, "speed", 40)))
+	}
+	if chase := luaTable(ai, "chase"); chase != nil {
+		behaviours = append(behaviours, NewChaseBehaviour(luaFloat(chase, "radius", 200), luaFloat(chase, "speed", 30),
+			luaFloat(chase, "stopDistance", 0)))
+	}
+	if patrol := luaTable(ai, "patrol"); patrol != nil {
+		var waypoints []Vec2
+		forEachIndexed(luaTable(patrol, "waypoints"), func(waypoint *lua.LTable) error {
+			waypoints = append(waypoints, Vec2{luaFloat(waypoint, "x", 0), luaFloat(waypoint, "y", 0)})
+			return nil
+		})
+		if len(waypoints) == 0 {
+			return nil, fmt.Errorf("patrol has no waypoints")
+		}
+		behaviours = append(behaviours, NewPatrolBehaviour(waypoints, luaFloat(patrol, "speed", 20), luaBool(patrol, "loop", true)))
+	}
+	if turret := luaTable(ai, "turret"); turret != nil {
+		behaviours = append(behaviours, NewTurretBehaviour(luaFloat(turret, "range", 250), luaFloat(turret, "turnRate", 90),
+			luaFloat(turret, "tolerance", 5), luaFloat(turret, "angle", 0)))
+	}
+	return behaviours, nil
+}
+
+// Level tables are indexed from [0], so they can't be walked with ipairs semantics
+func forEachIndexed(tbl *lua.LTable, fn func(*lua.LTable) error) error {
+	if tbl == nil {
+		return nil
+	}
+	for i := 0; ; i++ {
+		entry, ok := tbl.RawGet(lua.LNumber(i)).(*lua.LTable)
+		if !ok {
+			return nil
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+func luaTable(tbl *lua.LTable, key string) *lua.LTable {
+	if tbl == nil {
+		return nil
+	}
+	value, _ := tbl.RawGetString(key).(*lua.LTable)
+	return value
+}
+
+func luaString(tbl *lua.LTable, key, fallback string) string {
+	if tbl == nil {
+		return fallback
+	}
+	if value, ok := tbl.RawGetString(key).(lua.LString); ok {
+		return string(value)
+	}
+	return fallback
+}
+
+func luaFloat(tbl *lua.LTable, key string, fallback float64) float64 {
+	if tbl == nil {
+		return fallback
+	}
+	if value, ok := tbl.RawGetString(key).(lua.LNumber); ok {
+		return float64(value)
+	}
+	return fallback
+}
+
+// luaVec2 reads an {x, y} table, missing coordinates falling back to those
+// of fallback, or a single number used for both.
+func luaVec2(tbl *lua.LTable, key string, fallback Vec2) Vec2 {
+	if number := luaFloat(tbl, key, math.NaN()); !math.IsNaN(number) {
+		return Vec2{number, number}
+	}
+	value := luaTable(tbl, key)
+	return Vec2{luaFloat(value, "x", fallback.X()), luaFloat(value, "y", fallback.Y())}
+}
+
+func luaInt(tbl *lua.LTable, key string, fallback int) int {
+	return int(luaFloat(tbl, key, float64(fallback)))
+}
+
+func luaBool(tbl *lua.LTable, key string, fallback bool) bool {
+	if tbl == nil {
+		return fallback
+	}
+	if value, ok := tbl.RawGetString(key).(lua.LBool); ok {
+		return bool(value)
+	}
+	return fallback
+}
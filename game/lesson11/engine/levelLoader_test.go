@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func runLua(t *testing.T, L *lua.LState, script string) *lua.LTable {
+	t.Helper()
+	if err := L.DoString("data = " + script); err != nil {
+		t.Fatal(err)
+	}
+	return L.GetGlobal("data").(*lua.LTable)
+}
+
+func TestLoadEntities(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	manager := &EntityManager{}
+	loader := &LevelLoader{manager: manager, assetManager: &AssetManager{}}
+	err := loader.loadEntities(runLua(t, L, `{
+		[0] = { name = "tank", layer = 2, components = {
+			transform = { position = { x = 10, y = 20 }, velocity = { x = 5, y = 0 }, width = 32, height = 16, scale = 2 },
+			collider = { tag = "ENEMY" }
+		} },
+		[1] = { name = "empty" }
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entities := manager.GetEntities()
+	if len(entities) != 2 || entities[0].name != "tank" || entities[1].name != "empty" {
+		t.Fatalf("loaded %v, want tank and empty", entities)
+	}
+	transform := entities[0].GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	if transform.position != (Vec2{10, 20}) || transform.velocity != (Vec2{5, 0}) || transform.width != 32 || transform.scale != (Vec2{2, 2}) {
+		t.Errorf("tank transform %+v", transform)
+	}
+	collider := entities[0].GetComponent(COLLIDER_COMPONENT).(*ColliderComponent)
+	if collider.colliderTag != "ENEMY" || collider.collider.W != 64 || collider.collider.H != 32 {
+		t.Errorf("tank collider %+v, want a 64x32 ENEMY", collider)
+	}
+}
+
+func TestLoadEntitiesWithoutTransform(t *testing.T) {
+	for _, components := range []string{
+		`{ sprite = { textureAssetId = "tank-texture" } }`,
+		`{ input = { keyboard = { up = "w" } } }`,
+	} {
+		L := lua.NewState()
+		loader := &LevelLoader{manager: &EntityManager{}, assetManager: &AssetManager{}}
+		if err := loader.loadEntities(runLua(t, L, `{ [0] = { name = "ghost", components = `+components+` } }`)); err == nil {
+			t.Errorf("entity with %s and no transform loaded", components)
+		}
+		L.Close()
+	}
+}
+
+func TestLoadEntitiesWithBadKey(t *testing.T) {
+	L := lua.NewState()
+	defer L.Close()
+	loader := &LevelLoader{manager: newTestManager(), assetManager: &AssetManager{}}
+	err := loader.loadEntities(runLua(t, L, `{ [0] = { name = "player", components = {
+		transform = { width = 32, height = 32 },
+		input = { keyboard = { up = "w", shoot = "notakey" } }
+	} } }`))
+	if err == nil {
+		t.Error("player with an unknown shoot key loaded")
+	}
+}
@@ -11,9 +11,10 @@ import (
 
 type Map struct {
 	manager   *EntityManager
-	texture   *sdl.Texture
+	texture   Texture
 	scale     int
 	titleSize int
+	tilemap   *TilemapComponent
 }
 
 func (m *Map) LoadMap(filepath string, mapSizeX, mapSizeY int) error {
@@ -24,19 +25,23 @@ func (m *Map) LoadMap(filepath string, mapSizeX, mapSizeY int) error {
 	defer file.Close()
 	reader := bufio.NewReader(file)
 
+	m.tilemap = NewTilemapComponent(mapSizeX, mapSizeY, m.titleSize, m.titleSize, m.scale)
+	m.manager.AddEntity("tilemap", TILEMAP_LAYER).AddComponent(m.tilemap, TILEMAP_COMPONENT)
+
 	for y := range mapSizeY {
 		for x := range mapSizeX {
-			n, err := readDigit(reader)
+			row, err := readDigit(reader)
 			if err != nil {
 				return fmt.Errorf("failed to read digit %v", err)
 			}
-			sourceRectY := n * m.titleSize
-			n, err = readDigit(reader)
+			column, err := readDigit(reader)
 			if err != nil {
 				return fmt.Errorf("failed to read digit %v", err)
 			}
-			sourceRectX := n * m.titleSize
-			m.AddTile(sourceRectX, sourceRectY, x*m.scale*m.titleSize, y*m.scale*m.titleSize)
+			// A tile's id is the two digits as written, row then column
+			if err := m.AddTile(row*10+column, column*m.titleSize, row*m.titleSize, x, y); err != nil {
+				return err
+			}
 
 			_, err = reader.ReadByte()
 			if err != nil {
@@ -48,9 +53,9 @@ func (m *Map) LoadMap(filepath string, mapSizeX, mapSizeY int) error {
 	return nil
 }
 
-func (m *Map) AddTile(sourceRectX, sourceRectY, x, y int) {
-	tile := m.manager.AddEntity("tile", TILEMAP_LAYER)
-	tile.AddComponent(NewTileComponent(sourceRectX, sourceRectY, x, y, m.titleSize, m.scale, m.texture), TILE_COMPONENT)
+func (m *Map) AddTile(id, sourceRectX, sourceRectY, x, y int) error {
+	source := sdl.Rect{X: int32(sourceRectX), Y: int32(sourceRectY), W: int32(m.titleSize), H: int32(m.titleSize)}
+	return m.tilemap.AddTile(x, y, id, m.texture, source, sdl.FLIP_NONE)
 }
 
 func readDigit(r *bufio.Reader) (int, error) {
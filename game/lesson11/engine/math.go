@@ -16,6 +16,10 @@ func (v Vec2) Sub(o Vec2) Vec2 {
 	return Vec2{v.X() - o.X(), v.Y() - o.Y()}
 }
 
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{v[0] + o[0], v[1] + o[1]}
+}
+
 func (v Vec2) Dot(o Vec2) float64 {
 	return v[0]*o[0] + v[1]*o[1]
 }
@@ -24,6 +28,26 @@ func (v Vec2) Length() float64 {
 	return math.Sqrt(v.Dot(v))
 }
 
+// rotate turns v by angle degrees, clockwise on screen where y points down.
+func rotate(v Vec2, angle float64) Vec2 {
+	sin, cos := math.Sincos(Radians(angle))
+	return Vec2{v.X()*cos - v.Y()*sin, v.X()*sin + v.Y()*cos}
+}
+
 func Radians(angle float64) float64 {
 	return angle * math.Pi / 180
 }
+
+func Degrees(angle float64) float64 {
+	return angle * 180 / math.Pi
+}
+
+func Clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,268 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const MINIMAP_BLIP_SIZE = 3
+
+var (
+	MINIMAP_BACKGROUND_COLOR = sdl.Color{R: 10, G: 20, B: 10, A: 255}
+	MINIMAP_BORDER_COLOR     = sdl.Color{R: 120, G: 200, B: 120, A: 255}
+	MINIMAP_TARGET_COLOR     = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+)
+
+// MinimapComponent shows the level around a target entity, usually the
+// player, at its entity's place on screen. The ground is the TILEMAP_LAYER
+// tilemap drawn small; entities whose collider tag has a color are shown as
+// blips. It covers scope world pixels from the target to its edge, clipped to
+// a circle unless square.
+type MinimapComponent struct {
+	owner      *Entity
+	transform  *TransformComponent
+	targetName string
+	target     *Entity
+	scope      float64
+	square     bool
+	blips      map[string]sdl.Color
+	texture    Texture
+	ground     Texture
+	size       int32
+	tilemap    *TilemapComponent
+	drawing    uint64
+	drawn      [2]int
+	destroyed  SubscriptionId
+}
+
+func NewMinimapComponent(targetName string, scope float64, square bool, blips map[string]sdl.Color) *MinimapComponent {
+	return &MinimapComponent{targetName: targetName, scope: scope, square: square, blips: blips}
+}
+
+func (c *MinimapComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *MinimapComponent) Initialize() {
+	c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	c.size = int32(min(c.transform.Size().X(), c.transform.Size().Y()))
+
+	events := c.owner.manager.GetEventBus()
+	c.destroyed = Subscribe(events, func(e EntityDestroyedEvent) {
+		if e.entity == c.owner {
+			c.release()
+			events.Unsubscribe(c.destroyed)
+		}
+	})
+}
+
+func (c *MinimapComponent) release() {
+	for _, texture := range []*Texture{&c.texture, &c.ground} {
+		if *texture != nil {
+			(*texture).Destroy()
+			*texture = nil
+		}
+	}
+	c.tilemap = nil
+}
+
+func (c *MinimapComponent) Update(deltaTime float64) {}
+
+// scale is how many minimap pixels a world pixel takes.
+func (c *MinimapComponent) scale() float64 {
+	return float64(c.size) / 2 / c.scope
+}
+
+// inside reports whether an offset in minimap pixels from the center shows.
+func (c *MinimapComponent) inside(x, y float64) bool {
+	radius := float64(c.size) / 2
+	if c.square {
+		return math.Abs(x) <= radius && math.Abs(y) <= radius
+	}
+	return x*x+y*y <= radius*radius
+}
+
+func (c *MinimapComponent) Render(renderer Renderer) {
+	manager := c.owner.manager
+	if c.size <= 0 || c.scope <= 0 {
+		return
+	}
+	if c.target == nil || !c.target.IsActive() {
+		c.target = manager.GetEntityByName(c.targetName)
+	}
+	if c.target == nil || !c.target.HasComponent(TRANSFORM_COMPONENT) {
+		return
+	}
+	target := c.target.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	center := c.centerOf(target, manager.alpha)
+
+	if c.texture == nil {
+		texture, err := renderer.CreateTexture(c.size, c.size, true)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		c.texture = texture
+		c.tilemap = nil
+	}
+
+	// The ground is only drawn again once the view moved a whole minimap pixel
+	drawn := [2]int{int(math.Floor(center.X() * c.scale())), int(math.Floor(center.Y() * c.scale()))}
+	tilemap := manager.BaseTilemap()
+	redrawGround := tilemap != c.tilemap || (tilemap != nil && tilemap.DrawingVersion() != c.drawing)
+	if redrawGround || drawn != c.drawn {
+		if redrawGround {
+			if err := c.bakeGround(renderer, tilemap); err != nil {
+				fmt.Println(err)
+			}
+		}
+		c.drawn = drawn
+		if err := c.drawView(renderer); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	origin := sdl.Rect{X: int32(c.transform.position.X()), Y: int32(c.transform.position.Y()), W: c.size, H: c.size}
+	renderer.DrawSprite(c.texture, sdl.Rect{W: c.size, H: c.size}, origin, 0, nil, sdl.FLIP_NONE)
+
+	middle := Vec2{float64(origin.X) + float64(c.size)/2, float64(origin.Y) + float64(c.size)/2}
+	for _, entity := range manager.Query(COLLIDER_COMPONENT, TRANSFORM_COMPONENT) {
+		color, ok := c.blips[entity.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent).colliderTag]
+		if !ok || !entity.IsEnabled() || entity == c.target {
+			continue
+		}
+		offset := c.centerOf(entity.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent), manager.alpha).Sub(center)
+		x, y := offset.X()*c.scale(), offset.Y()*c.scale()
+		if c.inside(x, y) {
+			c.drawBlip(renderer, middle.Add(Vec2{x, y}), color)
+		}
+	}
+	c.drawBlip(renderer, middle, MINIMAP_TARGET_COLOR)
+}
+
+func (c *MinimapComponent) centerOf(transform *TransformComponent, alpha float64) Vec2 {
+	position := transform.Interpolated(alpha)
+	return position.Add(transform.Center().Sub(transform.position))
+}
+
+func (c *MinimapComponent) drawBlip(renderer Renderer, position Vec2, color sdl.Color) {
+	renderer.FillRect(sdl.Rect{
+		X: int32(math.Round(position.X())) - MINIMAP_BLIP_SIZE/2,
+		Y: int32(math.Round(position.Y())) - MINIMAP_BLIP_SIZE/2,
+		W: MINIMAP_BLIP_SIZE,
+		H: MINIMAP_BLIP_SIZE,
+	}, color)
+}
+
+// bakeGround draws the whole tilemap at the minimap's scale into the ground
+// texture, each tile flipped and turned the way the tilemap draws it.
+func (c *MinimapComponent) bakeGround(renderer Renderer, tilemap *TilemapComponent) error {
+	if c.ground != nil {
+		c.ground.Destroy()
+		c.ground = nil
+	}
+	c.tilemap = tilemap
+	if tilemap == nil {
+		return nil
+	}
+	c.drawing = tilemap.DrawingVersion()
+
+	width, height := tilemap.PixelSize()
+	ground, err := renderer.CreateTexture(int32(math.Ceil(float64(width)*c.scale())), int32(math.Ceil(float64(height)*c.scale())), true)
+	if err != nil {
+		return fmt.Errorf("failed to create minimap ground: %v", err)
+	}
+	c.ground = ground
+	if err := renderer.SetTarget(ground); err != nil {
+		return fmt.Errorf("failed to draw minimap ground: %v", err)
+	}
+	defer renderer.SetTarget(nil)
+	renderer.Clear(sdl.Color{})
+
+	// Tiles are anchored to the bottom left of their cell, as the tilemap bakes them
+	scale := float64(tilemap.scale) * c.scale()
+	for y := range tilemap.height {
+		for x := range tilemap.width {
+			for i := tilemap.first[y*tilemap.width+x]; i != 0; i = tilemap.tiles[i-1].next {
+				t := &tilemap.tiles[i-1]
+				left := float64(x*tilemap.tileWidth) * scale
+				bottom := float64((y+1)*tilemap.tileHeight) * scale
+				destination := sdl.Rect{
+					X: int32(math.Round(left)),
+					Y: int32(math.Round(bottom - float64(t.sourceRectangle.H)*scale)),
+				}
+				destination.W = max(int32(math.Round(left+float64(t.sourceRectangle.W)*scale))-destination.X, 1)
+				destination.H = max(int32(math.Round(bottom))-destination.Y, 1)
+				renderer.DrawSprite(t.texture, t.sourceRectangle, destination, t.angle, nil, t.flip)
+			}
+		}
+	}
+	return nil
+}
+
+// drawView redraws the minimap texture around the last drawn center, a row
+// at a time: the border, and inside it the ground over the background color.
+// Everything outside the circle stays transparent.
+func (c *MinimapComponent) drawView(renderer Renderer) error {
+	if err := renderer.SetTarget(c.texture); err != nil {
+		return fmt.Errorf("failed to draw minimap: %v", err)
+	}
+	defer renderer.SetTarget(nil)
+	renderer.Clear(sdl.Color{})
+
+	const (
+		outside = iota
+		border
+		inside
+	)
+	radius := float64(c.size) / 2
+	kind := func(px, py int32) int {
+		x, y := float64(px)+0.5-radius, float64(py)+0.5-radius
+		switch {
+		case !c.inside(x, y):
+			return outside
+		case !c.inside(x+math.Copysign(1, x), y+math.Copysign(1, y)):
+			return border
+		}
+		return inside
+	}
+
+	for py := range c.size {
+		for px := int32(0); px < c.size; {
+			run := kind(px, py)
+			end := px + 1
+			for end < c.size && kind(end, py) == run {
+				end++
+			}
+			switch run {
+			case border:
+				renderer.FillRect(sdl.Rect{X: px, Y: py, W: end - px, H: 1}, MINIMAP_BORDER_COLOR)
+			case inside:
+				renderer.FillRect(sdl.Rect{X: px, Y: py, W: end - px, H: 1}, MINIMAP_BACKGROUND_COLOR)
+				c.drawGroundRow(renderer, px, end, py)
+			}
+			px = end
+		}
+	}
+	return nil
+}
+
+// drawGroundRow copies the ground under minimap pixels from to end of row y.
+func (c *MinimapComponent) drawGroundRow(renderer Renderer, from, end, y int32) {
+	if c.ground == nil {
+		return
+	}
+	width, height := c.ground.Size()
+	offsetX := int32(c.drawn[0]) - c.size/2
+	offsetY := int32(c.drawn[1]) - c.size/2
+	left := max(from+offsetX, 0)
+	right := min(end+offsetX, width)
+	row := y + offsetY
+	if left >= right || row < 0 || row >= height {
+		return
+	}
+	source := sdl.Rect{X: left, Y: row, W: right - left, H: 1}
+	renderer.DrawSprite(c.ground, source, sdl.Rect{X: left - offsetX, Y: y, W: source.W, H: 1}, 0, nil, sdl.FLIP_NONE)
+}
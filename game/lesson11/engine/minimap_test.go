@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestMinimap(t *testing.T) {
+	renderer, manager := newHeadlessManager(t, 320, 240)
+
+	// A tile red on its left half and blue on its right
+	pixels := make([]byte, 16*16*4)
+	for i := 0; i < len(pixels); i += 4 {
+		if i/4%16 < 8 {
+			pixels[i+2] = 255
+		} else {
+			pixels[i] = 255
+		}
+		pixels[i+3] = 255
+	}
+	texture, err := renderer.CreateTexture(16, 16, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renderer.UpdateTexture(texture, sdl.Rect{W: 16, H: 16}, pixels, 16*4); err != nil {
+		t.Fatal(err)
+	}
+	source := sdl.Rect{W: 16, H: 16}
+	tilemap := NewTilemapComponent(4, 4, 16, 16, 1)
+	tilemap.AddTile(0, 0, 1, texture, source, sdl.FLIP_NONE)
+	tilemap.AddTile(1, 0, 1, texture, source, sdl.FLIP_HORIZONTAL)
+	tilemap.AddRotatedTile(2, 0, 1, texture, source, 90, sdl.FLIP_NONE)
+	manager.AddEntity("tilemap", TILEMAP_LAYER).AddComponent(tilemap, TILEMAP_COMPONENT)
+
+	player := manager.AddEntity("player", PLAYER_LAYER)
+	player.AddComponent(NewTransformComponent(Vec2{24, 24}, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	enemy := manager.AddEntity("enemy", ENEMY_LAYER)
+	enemy.AddComponent(NewTransformComponent(Vec2{0, 40}, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	enemy.AddComponent(NewColliderComponent("ENEMY", 0, 0, 16, 16), COLLIDER_COMPONENT)
+
+	// Both show the whole map a world pixel to a minimap pixel
+	blip := sdl.Color{R: 255, G: 60, B: 60, A: 255}
+	for i, square := range []bool{true, false} {
+		minimap := manager.AddEntity("minimap", UI_LAYER)
+		minimap.AddComponent(NewTransformComponent(Vec2{float64(100 * (i + 1)), 0}, Vec2{}, 64, 64, Vec2{1, 1}), TRANSFORM_COMPONENT)
+		minimap.AddComponent(NewMinimapComponent("player", 32, square, map[string]sdl.Color{"ENEMY": blip}), MINIMAP_COMPONENT)
+	}
+	manager.Render(1)
+
+	frame := renderer.Frame()
+	rgba := func(c sdl.Color) color.RGBA { return color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A} }
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	for _, test := range []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"plain tile", 104, 8, red},
+		{"flipped tile", 120, 8, blue},
+		{"top of the turned tile", 140, 4, red},
+		{"bottom of the turned tile", 140, 12, blue},
+		{"empty cell", 156, 40, rgba(MINIMAP_BACKGROUND_COLOR)},
+		{"square edge", 100, 30, rgba(MINIMAP_BORDER_COLOR)},
+		{"enemy", 108, 48, rgba(blip)},
+		{"player", 132, 32, rgba(MINIMAP_TARGET_COLOR)},
+		{"circle corner", 201, 1, color.RGBA{}},
+		{"circle edge", 200, 32, rgba(MINIMAP_BORDER_COLOR)},
+		{"circle inside", 220, 8, blue},
+	} {
+		if got := frame.RGBAAt(test.x, test.y); got != test.want {
+			t.Errorf("%s at %d,%d: %v, want %v", test.name, test.x, test.y, got, test.want)
+		}
+	}
+
+	// New tiles are drawn on the next frame
+	tilemap.AddTile(3, 2, 1, texture, source, sdl.FLIP_NONE)
+	manager.Render(1)
+	if got := frame.RGBAAt(152, 40); got != red {
+		t.Errorf("added tile shows %v", got)
+	}
+}
@@ -0,0 +1,301 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	MAX_PARTICLES         = 4096
+	DEFAULT_PARTICLE_SIZE = 4
+)
+
+type ParticleMode int
+
+const (
+	PARTICLE_BURST ParticleMode = iota
+	PARTICLE_CONTINUOUS
+)
+
+// ParticleStyle is how the particles of one emitter move and look. Sizes and
+// colors are curves over a particle's life, their keys evenly spaced from
+// birth to death. Without a texture particles are plain squares; with one,
+// its frameCount frames side by side play once over the particle's life.
+type ParticleStyle struct {
+	texture     Texture
+	frameWidth  int32
+	frameHeight int32
+	frameCount  int
+	minLifetime float64
+	maxLifetime float64
+	minSpeed    float64
+	maxSpeed    float64
+	angle       float64
+	spread      float64
+	gravity     Vec2
+	sizes       []float64
+	colors      []sdl.Color
+}
+
+type particle struct {
+	style    *ParticleStyle
+	position Vec2
+	previous Vec2
+	velocity Vec2
+	age      float64
+	lifetime float64
+}
+
+// ParticleSystem keeps every live particle of a scene in one packed slice,
+// so thousands of them cost no entities. A dead particle is swapped with the
+// last one, and once MAX_PARTICLES are alive new ones are dropped.
+type ParticleSystem struct {
+	particles []particle
+}
+
+func NewParticleSystem() *ParticleSystem {
+	return &ParticleSystem{}
+}
+
+// Emit spawns count particles of style at position, spread around the
+// style's angle.
+func (s *ParticleSystem) Emit(style *ParticleStyle, position Vec2, count int) {
+	for range count {
+		if len(s.particles) >= MAX_PARTICLES {
+			return
+		}
+		angle := style.angle + (rand.Float64()-0.5)*style.spread
+		speed := style.minSpeed + rand.Float64()*(style.maxSpeed-style.minSpeed)
+		s.particles = append(s.particles, particle{
+			style:    style,
+			position: position,
+			previous: position,
+			velocity: Vec2{math.Cos(angle) * speed, math.Sin(angle) * speed},
+			lifetime: style.minLifetime + rand.Float64()*(style.maxLifetime-style.minLifetime),
+		})
+	}
+}
+
+func (s *ParticleSystem) Count() int {
+	return len(s.particles)
+}
+
+func (s *ParticleSystem) Clear() {
+	s.particles = s.particles[:0]
+}
+
+func (s *ParticleSystem) Update(deltaTime float64) {
+	for i := 0; i < len(s.particles); {
+		p := &s.particles[i]
+		p.age += deltaTime
+		if p.age >= p.lifetime {
+			last := len(s.particles) - 1
+			s.particles[i] = s.particles[last]
+			s.particles = s.particles[:last]
+			continue
+		}
+		p.previous = p.position
+		p.velocity = p.velocity.Add(Vec2{p.style.gravity.X() * deltaTime, p.style.gravity.Y() * deltaTime})
+		p.position = p.position.Add(Vec2{p.velocity.X() * deltaTime, p.velocity.Y() * deltaTime})
+		i++
+	}
+}
+
+// Render draws the particles alpha of the way between the last two fixed
+// steps, through the camera when there is one.
+func (s *ParticleSystem) Render(renderer Renderer, camera *Camera, alpha float64) {
+	for i := range s.particles {
+		p := &s.particles[i]
+		t := p.age / p.lifetime
+		size := sampleCurve(p.style.sizes, t, DEFAULT_PARTICLE_SIZE)
+		color := sampleColors(p.style.colors, t)
+		if size <= 0 || color.A == 0 {
+			continue
+		}
+
+		position := Vec2{
+			p.previous.X() + (p.position.X()-p.previous.X())*alpha,
+			p.previous.Y() + (p.position.Y()-p.previous.Y())*alpha,
+		}
+		rect := sdl.Rect{
+			X: int32(math.Round(position.X() - size/2)),
+			Y: int32(math.Round(position.Y() - size/2)),
+			W: int32(math.Max(math.Round(size), 1)),
+			H: int32(math.Max(math.Round(size), 1)),
+		}
+		if camera != nil {
+			rect = camera.ToScreen(rect)
+		}
+
+		if p.style.texture == nil {
+			renderer.FillRect(rect, color)
+			continue
+		}
+		frame := min(int(t*float64(p.style.frameCount)), p.style.frameCount-1)
+		source := sdl.Rect{X: int32(frame) * p.style.frameWidth, W: p.style.frameWidth, H: p.style.frameHeight}
+		renderer.DrawTinted(p.style.texture, source, rect, color)
+	}
+}
+
+// sampleCurve reads evenly spaced keys at t in [0, 1], linearly between them.
+func sampleCurve(keys []float64, t, fallback float64) float64 {
+	switch len(keys) {
+	case 0:
+		return fallback
+	case 1:
+		return keys[0]
+	}
+	position := Clamp(t, 0, 1) * float64(len(keys)-1)
+	i := min(int(position), len(keys)-2)
+	return keys[i] + (keys[i+1]-keys[i])*(position-float64(i))
+}
+
+func sampleColors(keys []sdl.Color, t float64) sdl.Color {
+	switch len(keys) {
+	case 0:
+		return sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	case 1:
+		return keys[0]
+	}
+	position := Clamp(t, 0, 1) * float64(len(keys)-1)
+	i := min(int(position), len(keys)-2)
+	f := position - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*f))
+	}
+	from, to := keys[i], keys[i+1]
+	return sdl.Color{R: lerp(from.R, to.R), G: lerp(from.G, to.G), B: lerp(from.B, to.B), A: lerp(from.A, to.A)}
+}
+
+// ParticleEmitter emits one style of particle from an entity. A burst emits
+// count particles at once; a continuous emitter rate particles a second for
+// duration seconds, or until stopped when duration is 0. Without a trigger
+// an emitter starts with its entity, otherwise whenever the trigger event
+// involves the entity.
+type ParticleEmitter struct {
+	style      *ParticleStyle
+	mode       ParticleMode
+	count      int
+	rate       float64
+	duration   float64
+	offset     Vec2
+	trigger    EventType
+	triggered  bool
+	active     bool
+	remaining  float64
+	pending    float64
+	subscribed SubscriptionId
+}
+
+func NewParticleEmitter(style *ParticleStyle, mode ParticleMode, count int, rate, duration float64, offset Vec2) *ParticleEmitter {
+	return &ParticleEmitter{style: style, mode: mode, count: count, rate: rate, duration: duration, offset: offset}
+}
+
+// SetTrigger starts the emitter on every event of type trigger involving its
+// entity, instead of once when the entity starts.
+func (e *ParticleEmitter) SetTrigger(trigger EventType) {
+	e.trigger = trigger
+	e.triggered = true
+}
+
+// ParticleEmitterComponent emits its entity's particles into the manager's
+// ParticleSystem, so the particles outlive the entity, e.g. an explosion
+// from a tank that was just destroyed.
+type ParticleEmitterComponent struct {
+	owner     *Entity
+	transform *TransformComponent
+	emitters  []*ParticleEmitter
+	destroyed SubscriptionId
+}
+
+func NewParticleEmitterComponent(emitters ...*ParticleEmitter) *ParticleEmitterComponent {
+	return &ParticleEmitterComponent{emitters: emitters}
+}
+
+func (c *ParticleEmitterComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *ParticleEmitterComponent) Initialize() {
+	c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+
+	events := c.owner.manager.GetEventBus()
+	for _, emitter := range c.emitters {
+		if !emitter.triggered {
+			c.Start(emitter)
+			continue
+		}
+		emitter.subscribed = events.SubscribeType(emitter.trigger, func(event Event) {
+			if Involves(event, c.owner) {
+				c.Start(emitter)
+			}
+		})
+	}
+
+	c.destroyed = Subscribe(events, func(e EntityDestroyedEvent) {
+		if e.entity == c.owner {
+			for _, emitter := range c.emitters {
+				if emitter.triggered {
+					events.Unsubscribe(emitter.subscribed)
+				}
+			}
+			events.Unsubscribe(c.destroyed)
+		}
+	})
+}
+
+// Start fires a burst emitter, or turns a continuous one on.
+func (c *ParticleEmitterComponent) Start(emitter *ParticleEmitter) {
+	if emitter.mode == PARTICLE_BURST {
+		c.owner.manager.GetParticleSystem().Emit(emitter.style, c.origin(emitter), emitter.count)
+		return
+	}
+	emitter.active = true
+	emitter.remaining = emitter.duration
+}
+
+func (c *ParticleEmitterComponent) Stop(emitter *ParticleEmitter) {
+	emitter.active = false
+	emitter.pending = 0
+}
+
+func (c *ParticleEmitterComponent) origin(emitter *ParticleEmitter) Vec2 {
+	return c.transform.Center().Add(emitter.offset)
+}
+
+func (c *ParticleEmitterComponent) Update(deltaTime float64) {
+	particles := c.owner.manager.GetParticleSystem()
+	for _, emitter := range c.emitters {
+		if !emitter.active {
+			continue
+		}
+		// Fractions of a particle carry over, so low rates still emit
+		emitter.pending += emitter.rate * deltaTime
+		if count := int(emitter.pending); count > 0 {
+			particles.Emit(emitter.style, c.origin(emitter), count)
+			emitter.pending -= float64(count)
+		}
+		if emitter.duration > 0 {
+			emitter.remaining -= deltaTime
+			if emitter.remaining <= 0 {
+				c.Stop(emitter)
+			}
+		}
+	}
+}
+
+func (c *ParticleEmitterComponent) Render(renderer Renderer) {}
+
+// ParseParticleMode reads "burst" or "continuous".
+func ParseParticleMode(mode string) (ParticleMode, error) {
+	switch mode {
+	case "burst":
+		return PARTICLE_BURST, nil
+	case "continuous":
+		return PARTICLE_CONTINUOUS, nil
+	}
+	return PARTICLE_BURST, fmt.Errorf("unknown particle mode %q", mode)
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestParticleCap(t *testing.T) {
+	particles := NewParticleSystem()
+	style := &ParticleStyle{minLifetime: 1, maxLifetime: 1}
+	particles.Emit(style, Vec2{}, MAX_PARTICLES+10)
+	if particles.Count() != MAX_PARTICLES {
+		t.Errorf("%d particles alive, want %d", particles.Count(), MAX_PARTICLES)
+	}
+	particles.Update(1)
+	if particles.Count() != 0 {
+		t.Errorf("%d particles outlived their lifetime", particles.Count())
+	}
+}
+
+func TestParticleEmitters(t *testing.T) {
+	manager := newTestManager()
+	entity := manager.AddEntity("tank", ENEMY_LAYER)
+	entity.AddComponent(NewTransformComponent(Vec2{}, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	style := &ParticleStyle{minLifetime: 10, maxLifetime: 10}
+	burst := NewParticleEmitter(style, PARTICLE_BURST, 30, 0, 0, Vec2{})
+	burst.SetTrigger(ENTITY_KILLED_EVENT)
+	smoke := NewParticleEmitter(style, PARTICLE_CONTINUOUS, 0, 10, 1, Vec2{})
+	entity.AddComponent(NewParticleEmitterComponent(burst, smoke), PARTICLE_EMITTER_COMPONENT)
+	particles := manager.GetParticleSystem()
+
+	// Fractions of a particle add up across steps
+	for range 10 {
+		manager.Update(0.05)
+	}
+	if particles.Count() != 5 {
+		t.Errorf("half a second at 10 a second emitted %d particles", particles.Count())
+	}
+	for range 20 {
+		manager.Update(0.05)
+	}
+	if particles.Count() != 10 {
+		t.Errorf("a continuous emitter lasting 1s emitted %d particles", particles.Count())
+	}
+
+	manager.GetEventBus().Publish(EntityKilledEvent{manager.AddEntity("other", ENEMY_LAYER), nil})
+	if particles.Count() != 10 {
+		t.Error("a burst went off for another entity's death")
+	}
+	manager.GetEventBus().Publish(EntityKilledEvent{entity, nil})
+	if particles.Count() != 40 {
+		t.Errorf("%d particles after the burst, want 40", particles.Count())
+	}
+}
+
+func TestParticleCurves(t *testing.T) {
+	for _, test := range []struct {
+		keys []float64
+		t    float64
+		want float64
+	}{
+		{nil, 0.5, DEFAULT_PARTICLE_SIZE},
+		{[]float64{3}, 0.7, 3},
+		{[]float64{0, 10}, 0.25, 2.5},
+		{[]float64{0, 10, 4}, 0.75, 7},
+		{[]float64{0, 10, 4}, 1.5, 4},
+	} {
+		if got := sampleCurve(test.keys, test.t, DEFAULT_PARTICLE_SIZE); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%v at %v: %v, want %v", test.keys, test.t, got, test.want)
+		}
+	}
+
+	colors := []sdl.Color{{R: 255, A: 255}, {B: 255}}
+	if got := sampleColors(colors, 0.5); got != (sdl.Color{R: 128, B: 128, A: 128}) {
+		t.Errorf("halfway from red to clear blue: %v", got)
+	}
+}
@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"container/heap"
+	"math"
+)
+
+const PATH_CACHE_SIZE = 256
+
+type cell struct {
+	x, y int
+}
+
+type pathKey struct {
+	start, goal cell
+}
+
+// PathFinder searches routes with A* over a tilemap's walkability grid.
+// Entities move diagonally only where neither neighbouring cell is blocked,
+// so they never cut a corner. Results are smoothed and cached per pair of
+// cells until the grid changes.
+type PathFinder struct {
+	tilemap *TilemapComponent
+	version uint64
+	cache   map[pathKey][]cell
+	nodes   []pathNode
+	search  uint32
+	open    pathQueue
+}
+
+type pathNode struct {
+	search uint32
+	closed bool
+	cost   float64
+	parent int
+}
+
+func NewPathFinder(tilemap *TilemapComponent) *PathFinder {
+	return &PathFinder{tilemap: tilemap, version: tilemap.Version(), cache: make(map[pathKey][]cell)}
+}
+
+func (p *PathFinder) Tilemap() *TilemapComponent {
+	return p.tilemap
+}
+
+// FindPath is the route in world coordinates from one position to another:
+// the corners to head for in turn, ending at to itself. It fails when either
+// end is off the map, the goal is blocked or no route gets there.
+func (p *PathFinder) FindPath(from, to Vec2) ([]Vec2, bool) {
+	startX, startY, ok := p.tilemap.CellAt(from)
+	if !ok {
+		return nil, false
+	}
+	goalX, goalY, ok := p.tilemap.CellAt(to)
+	if !ok || !p.tilemap.IsWalkable(goalX, goalY) {
+		return nil, false
+	}
+
+	if p.version != p.tilemap.Version() {
+		clear(p.cache)
+		p.version = p.tilemap.Version()
+	}
+	key := pathKey{cell{startX, startY}, cell{goalX, goalY}}
+	cells, ok := p.cache[key]
+	if !ok {
+		cells = p.smooth(p.find(key.start, key.goal))
+		// Caching is cheap to undo, so a full cache is simply started over
+		if len(p.cache) >= PATH_CACHE_SIZE {
+			clear(p.cache)
+		}
+		p.cache[key] = cells
+	}
+	if cells == nil {
+		return nil, false
+	}
+
+	// The first cell is where the search started, already reached, and the
+	// last is replaced by the exact goal
+	path := make([]Vec2, 0, len(cells))
+	for _, c := range cells[1:max(len(cells)-1, 1)] {
+		path = append(path, p.tilemap.CellCenter(c.x, c.y))
+	}
+	return append(path, to), true
+}
+
+// find runs A* from start to goal with the octile distance as heuristic,
+// which never overestimates since no cell costs less than 1.
+func (p *PathFinder) find(start, goal cell) []cell {
+	width, height := p.tilemap.Size()
+	if len(p.nodes) != width*height {
+		p.nodes = make([]pathNode, width*height)
+		p.search = 0
+	}
+	p.search++
+	p.open = p.open[:0]
+
+	visit := func(c cell, cost float64, parent int) {
+		node := &p.nodes[c.y*width+c.x]
+		if node.search == p.search && (node.closed || node.cost <= cost) {
+			return
+		}
+		*node = pathNode{search: p.search, cost: cost, parent: parent}
+		heap.Push(&p.open, pathEntry{c, cost + octile(c, goal)})
+	}
+	visit(start, 0, -1)
+
+	for p.open.Len() > 0 {
+		current := heap.Pop(&p.open).(pathEntry).cell
+		index := current.y*width + current.x
+		node := &p.nodes[index]
+		if node.closed {
+			continue
+		}
+		node.closed = true
+		if current == goal {
+			return p.walkBack(index, width)
+		}
+
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				next := cell{current.x + dx, current.y + dy}
+				if (dx == 0 && dy == 0) || !p.tilemap.IsWalkable(next.x, next.y) {
+					continue
+				}
+				step := 1.0
+				if dx != 0 && dy != 0 {
+					if !p.tilemap.IsWalkable(current.x+dx, current.y) || !p.tilemap.IsWalkable(current.x, current.y+dy) {
+						continue
+					}
+					step = math.Sqrt2
+				}
+				visit(next, node.cost+step*p.tilemap.Cost(next.x, next.y), index)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *PathFinder) walkBack(index, width int) []cell {
+	var cells []cell
+	for ; index >= 0; index = p.nodes[index].parent {
+		cells = append(cells, cell{index % width, index / width})
+	}
+	for i, j := 0, len(cells)-1; i < j; i, j = i+1, j-1 {
+		cells[i], cells[j] = cells[j], cells[i]
+	}
+	return cells
+}
+
+func octile(a, b cell) float64 {
+	dx := math.Abs(float64(a.x - b.x))
+	dy := math.Abs(float64(a.y - b.y))
+	return max(dx, dy) + (math.Sqrt2-1)*min(dx, dy)
+}
+
+// smooth drops the cells a path can go straight past, keeping only the
+// corners. Straight lines never cross cells costlier than their ends, so
+// smoothing doesn't trade a detour for a swamp.
+func (p *PathFinder) smooth(cells []cell) []cell {
+	if len(cells) <= 2 {
+		return cells
+	}
+	smoothed := []cell{cells[0]}
+	for i := 2; i < len(cells); i++ {
+		if !p.lineOfSight(smoothed[len(smoothed)-1], cells[i]) {
+			smoothed = append(smoothed, cells[i-1])
+		}
+	}
+	return append(smoothed, cells[len(cells)-1])
+}
+
+// lineOfSight walks every cell the line between two cell centers touches.
+// Where it passes exactly through a corner both cells beside it must be
+// clear too.
+func (p *PathFinder) lineOfSight(from, to cell) bool {
+	limit := max(p.tilemap.Cost(from.x, from.y), p.tilemap.Cost(to.x, to.y))
+	passable := func(x, y int) bool {
+		return p.tilemap.IsWalkable(x, y) && p.tilemap.Cost(x, y) <= limit
+	}
+
+	nx, ny := abs(to.x-from.x), abs(to.y-from.y)
+	sx, sy := sign(to.x-from.x), sign(to.y-from.y)
+	x, y := from.x, from.y
+	for ix, iy := 0, 0; ix < nx || iy < ny; {
+		switch decision := (1+2*ix)*ny - (1+2*iy)*nx; {
+		case decision == 0:
+			if !passable(x+sx, y) || !passable(x, y+sy) {
+				return false
+			}
+			x, y = x+sx, y+sy
+			ix, iy = ix+1, iy+1
+		case decision < 0:
+			x, ix = x+sx, ix+1
+		default:
+			y, iy = y+sy, iy+1
+		}
+		if !passable(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+type pathEntry struct {
+	cell
+	estimate float64
+}
+
+type pathQueue []pathEntry
+
+func (q pathQueue) Len() int           { return len(q) }
+func (q pathQueue) Less(i, j int) bool { return q[i].estimate < q[j].estimate }
+func (q pathQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x any)        { *q = append(*q, x.(pathEntry)) }
+
+func (q *pathQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+// newNavigation is a width by height tilemap of 10 pixel cells with the
+// given cells blocked.
+func newNavigation(width, height int, blocked ...cell) (*TilemapComponent, *PathFinder) {
+	tilemap := NewTilemapComponent(width, height, 10, 10, 1)
+	for _, c := range blocked {
+		tilemap.SetWalkable(c.x, c.y, false)
+	}
+	return tilemap, NewPathFinder(tilemap)
+}
+
+func TestFindPathAroundWalls(t *testing.T) {
+	tilemap, finder := newNavigation(5, 5, cell{2, 0}, cell{2, 1}, cell{2, 2}, cell{2, 3})
+	if cells := finder.find(cell{0, 0}, cell{4, 0}); !slices.Contains(cells, cell{2, 4}) {
+		t.Errorf("route %v doesn't go through the gap at 2,4", cells)
+	}
+
+	to := Vec2{43, 2}
+	path, ok := finder.FindPath(tilemap.CellCenter(0, 0), to)
+	if !ok || path[len(path)-1] != to {
+		t.Fatalf("path %v, want one ending at %v", path, to)
+	}
+	from := cell{0, 0}
+	for _, point := range path {
+		x, y, _ := tilemap.CellAt(point)
+		if !finder.lineOfSight(from, cell{x, y}) {
+			t.Errorf("path %v walks through a wall between %v and %d,%d", path, from, x, y)
+		}
+		from = cell{x, y}
+	}
+}
+
+func TestFindPathFailures(t *testing.T) {
+	tilemap, finder := newNavigation(4, 4, cell{1, 0}, cell{0, 1}, cell{3, 2}, cell{2, 3})
+	for _, test := range []struct {
+		name     string
+		from, to cell
+	}{
+		{"corner cutting", cell{0, 0}, cell{1, 1}},
+		{"blocked goal", cell{2, 2}, cell{1, 0}},
+		{"walled in", cell{0, 0}, cell{3, 3}},
+	} {
+		if path, ok := finder.FindPath(tilemap.CellCenter(test.from.x, test.from.y), tilemap.CellCenter(test.to.x, test.to.y)); ok {
+			t.Errorf("%s: found %v", test.name, path)
+		}
+	}
+	if _, ok := finder.FindPath(Vec2{5, 5}, Vec2{-5, 5}); ok {
+		t.Error("found a path off the map")
+	}
+}
+
+func TestFindPathCosts(t *testing.T) {
+	tilemap, finder := newNavigation(5, 3)
+	for x := 1; x <= 3; x++ {
+		tilemap.SetCost(x, 1, 5)
+	}
+	cells := finder.find(cell{0, 1}, cell{4, 1})
+	for _, c := range cells[1 : len(cells)-1] {
+		if c.y == 1 {
+			t.Errorf("route %v crosses the costly middle row", cells)
+			break
+		}
+	}
+
+	// Smoothing keeps to the detour instead of cutting back across
+	path, ok := finder.FindPath(tilemap.CellCenter(0, 1), tilemap.CellCenter(4, 1))
+	if !ok || len(path) < 2 {
+		t.Errorf("path %v, want corners around the costly cells", path)
+	}
+}
+
+func TestFindPathCache(t *testing.T) {
+	tilemap, finder := newNavigation(5, 3)
+	from, to := tilemap.CellCenter(0, 0), tilemap.CellCenter(4, 0)
+	if path, ok := finder.FindPath(from, to); !ok || len(path) != 1 {
+		t.Fatalf("path %v, want straight to the goal", path)
+	}
+	if len(finder.cache) != 1 {
+		t.Errorf("%d paths cached, want 1", len(finder.cache))
+	}
+
+	version := tilemap.Version()
+	tilemap.SetWalkable(2, 0, false)
+	if tilemap.Version() == version {
+		t.Fatal("blocking a cell didn't change the tilemap's version")
+	}
+	if path, ok := finder.FindPath(from, to); !ok || len(path) < 2 {
+		t.Errorf("path %v after blocking 2,0, want a detour", path)
+	}
+}
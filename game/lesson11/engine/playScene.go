@@ -0,0 +1,293 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PlayScene runs one level. Score and lives live on the Game, so they carry
+// over from level to level.
+type PlayScene struct {
+	sceneScope
+	game        *Game
+	camera      *Camera
+	player      *Entity
+	levelNumber int
+	mapFile     string
+	finished    bool
+}
+
+func NewPlayScene(g *Game, levelNumber int) *PlayScene {
+	return &PlayScene{game: g, levelNumber: levelNumber}
+}
+
+func (s *PlayScene) Enter() error {
+	s.camera = NewCamera(WINDOW_WIDTH, WINDOW_HEIGHT)
+	s.open(s.game, s.camera)
+	s.RegisterCollisionHandlers()
+	s.RegisterEventHandlers()
+	return s.LoadLevel(s.levelNumber)
+}
+
+func (s *PlayScene) Exit() {
+	s.close()
+	s.player = nil
+}
+
+func (s *PlayScene) LoadLevel(levelNumber int) error {
+	s.levelNumber = levelNumber
+	loader := LevelLoader{manager: s.manager, assetManager: s.assetManager}
+	err := loader.LoadLevel(levelNumber)
+	s.mapFile = loader.MapFile()
+	if err != nil {
+		return err
+	}
+
+	s.player = s.manager.GetEntityByName("player")
+	if s.player == nil {
+		return fmt.Errorf("level %d has no player entity", levelNumber)
+	}
+	s.camera.SnapToTarget()
+	s.UpdateHUD()
+	return nil
+}
+
+func (s *PlayScene) Update(deltaTime float64) {
+	if s.game.input.ActionPressed("pause") {
+		s.game.PushScene(NewPauseScene(s.game))
+		return
+	}
+
+	s.manager.Update(deltaTime)
+	s.camera.Update(deltaTime)
+	s.CheckCollisions()
+	s.manager.GetEventBus().Flush()
+	s.UpdateHUD()
+}
+
+func (s *PlayScene) Render(alpha float64) {
+	if s.manager.HasNoEntities() {
+		return
+	}
+	s.camera.Interpolate(alpha)
+	s.manager.Render(alpha)
+}
+
+func (s *PlayScene) IsOverlay() bool {
+	return false
+}
+
+// Reload picks up changed files: textures are reloaded in place, a changed
+// .map file rebuilds the tilemap, and a changed level script or other level
+// asset reloads the whole level with the player where it was.
+func (s *PlayScene) Reload(files []string) error {
+	reloadLevel, rebuildMap, redrawMap := false, false, false
+	for _, file := range files {
+		switch {
+		case file == filepath.Clean(LevelScript(s.levelNumber)):
+			reloadLevel = true
+		case file == filepath.Clean(s.mapFile):
+			if strings.ToLower(filepath.Ext(file)) == ".map" {
+				rebuildMap = true
+			} else {
+				reloadLevel = true
+			}
+		default:
+			reloaded, err := s.assetManager.ReloadTextures(file)
+			if err != nil {
+				return err
+			}
+			if reloaded {
+				redrawMap = true
+			} else if s.assetManager.Uses(file) {
+				reloadLevel = true
+			}
+		}
+	}
+
+	switch {
+	case reloadLevel:
+		return s.reloadLevel()
+	case rebuildMap:
+		return s.rebuildMap()
+	case redrawMap:
+		// Tiles baked into the chunks still show the old texture
+		for _, entity := range s.manager.Query(TILEMAP_COMPONENT) {
+			entity.GetComponent(TILEMAP_COMPONENT).(*TilemapComponent).Invalidate()
+		}
+	}
+	return nil
+}
+
+func (s *PlayScene) reloadLevel() error {
+	var position Vec2
+	keepPosition := s.player != nil && s.player.HasComponent(TRANSFORM_COMPONENT)
+	if keepPosition {
+		position = s.player.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent).position
+	}
+
+	s.close()
+	s.player = nil
+	s.open(s.game, s.camera)
+	s.RegisterCollisionHandlers()
+	s.RegisterEventHandlers()
+	if err := s.LoadLevel(s.levelNumber); err != nil {
+		return err
+	}
+
+	if keepPosition && s.player.HasComponent(TRANSFORM_COMPONENT) {
+		s.player.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent).Teleport(position)
+		s.camera.SnapToTarget()
+	}
+	return nil
+}
+
+func (s *PlayScene) rebuildMap() error {
+	for _, entity := range s.manager.Query(TILEMAP_COMPONENT) {
+		entity.Destroy()
+	}
+	s.manager.DestroyInactiveEntities()
+
+	loader := LevelLoader{manager: s.manager, assetManager: s.assetManager}
+	if err := loader.ReloadMap(s.levelNumber); err != nil {
+		return err
+	}
+	s.camera.SetBounds(s.manager.MapSize())
+	return nil
+}
+
+// UpdateHUD shows the live values in whichever of the labelScore, labelLives
+// and labelHealth entities the level declares.
+func (s *PlayScene) UpdateHUD() {
+	setLabel := func(name, text string) {
+		if label := s.manager.GetEntityByName(name); label != nil && label.HasComponent(TEXT_LABEL_COMPONENT) {
+			if err := label.GetComponent(TEXT_LABEL_COMPONENT).(*TextLabelComponent).SetText(text); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+
+	setLabel("labelScore", fmt.Sprintf("Score: %d", s.game.score))
+	setLabel("labelLives", fmt.Sprintf("Lives: %d", s.game.lives))
+	if s.player != nil && s.player.HasComponent(HEALTH_COMPONENT) {
+		health := s.player.GetComponent(HEALTH_COMPONENT).(*HealthComponent)
+		setLabel("labelHealth", fmt.Sprintf("Health: %d/%d", health.Health(), health.MaxHealth()))
+	}
+}
+
+// RespawnPlayer puts the player back on the level's start entity with full
+// health and a moment of invulnerability.
+func (s *PlayScene) RespawnPlayer() {
+	transform := s.player.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	if start := s.manager.GetEntityByName("start"); start != nil && start.HasComponent(TRANSFORM_COMPONENT) {
+		transform.Teleport(start.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent).position)
+	}
+	transform.velocity = Vec2{0, 0}
+
+	if s.player.HasComponent(HEALTH_COMPONENT) {
+		s.player.GetComponent(HEALTH_COMPONENT).(*HealthComponent).Revive(PLAYER_RESPAWN_INVULNERABILITY)
+	}
+	s.camera.SnapToTarget()
+}
+
+func (s *PlayScene) CheckCollisions() {
+	s.manager.CheckCollisions()
+}
+
+func (s *PlayScene) RegisterCollisionHandlers() {
+	collisions := s.manager.GetCollisionMatrix()
+	events := s.manager.GetEventBus()
+
+	// Staying in contact keeps hurting once the invulnerability wears off
+	hurtPlayer := func(pair CollisionPair) {
+		if pair.state == COLLISION_EXIT {
+			return
+		}
+		// A pooled shot goes back to its pool once it has hit
+		if pair.state == COLLISION_ENTER && pair.that.HasComponent(PROJECTILE_COMPONENT) {
+			defer pair.that.GetComponent(PROJECTILE_COMPONENT).(*ProjectileComponent).Release()
+		}
+		if !pair.this.HasComponent(HEALTH_COMPONENT) {
+			if pair.state == COLLISION_ENTER {
+				events.Enqueue(GameOverEvent{pair.that})
+			}
+			return
+		}
+		ApplyDamage(pair.this, pair.that)
+	}
+	collisions.OnCollision(PLAYER_ENEMY_COLLISION, hurtPlayer)
+	collisions.OnCollision(PLAYER_PROJECTILE_COLLISION, hurtPlayer)
+
+	collisions.OnCollision(ENEMY_FRIENDLY_PROJECTILE_COLLISION, func(pair CollisionPair) {
+		if pair.state != COLLISION_ENTER {
+			return
+		}
+		if pair.this.HasComponent(HEALTH_COMPONENT) {
+			ApplyDamage(pair.this, pair.that)
+		} else {
+			pair.this.Destroy()
+		}
+		// Projectiles a level places itself aren't pooled
+		if pair.that.HasComponent(PROJECTILE_COMPONENT) {
+			pair.that.GetComponent(PROJECTILE_COMPONENT).(*ProjectileComponent).Release()
+		} else {
+			pair.that.Destroy()
+		}
+	})
+
+	collisions.OnCollision(PLAYER_LEVEL_COMPLETE_COLLISION, func(pair CollisionPair) {
+		if pair.state == COLLISION_ENTER {
+			events.Enqueue(LevelCompleteEvent{s.levelNumber})
+		}
+	})
+}
+
+// finish shows the scene ending the level; the level stays frozen beneath it.
+// Only the first ending counts, if several happen in one frame.
+func (s *PlayScene) finish(scene Scene) {
+	if !s.finished {
+		s.finished = true
+		s.game.PushScene(scene)
+	}
+}
+
+func (s *PlayScene) RegisterEventHandlers() {
+	events := s.manager.GetEventBus()
+	g := s.game
+
+	// Game Over
+	Subscribe(events, func(e GameOverEvent) {
+		s.finish(NewGameOverScene(g))
+	})
+
+	// Deaths
+	Subscribe(events, func(e EntityKilledEvent) {
+		if e.entity != s.player {
+			if e.entity.HasComponent(HEALTH_COMPONENT) {
+				g.score += e.entity.GetComponent(HEALTH_COMPONENT).(*HealthComponent).ScoreValue()
+			}
+			e.entity.Destroy()
+			events.Enqueue(CameraShakeEvent{0.3})
+			return
+		}
+
+		g.lives--
+		if g.lives <= 0 {
+			events.Enqueue(GameOverEvent{e.source})
+			return
+		}
+		events.Enqueue(CameraShakeEvent{0.6})
+		s.RespawnPlayer()
+	})
+
+	// Next Level
+	Subscribe(events, func(e LevelCompleteEvent) {
+		s.finish(NewLevelCompleteScene(g, e.levelNumber))
+	})
+
+	// Screen shake
+	Subscribe(events, func(e CameraShakeEvent) {
+		s.camera.AddTrauma(e.trauma)
+	})
+}
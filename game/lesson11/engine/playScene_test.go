@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// TestLevel1Headless plays the first level for two seconds with the player
+// flying right, the way a headless game would, and checks what it drew.
+func TestLevel1Headless(t *testing.T) {
+	if err := ttf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer ttf.Quit()
+
+	renderer, err := NewImageRenderer(WINDOW_WIDTH, WINDOW_HEIGHT, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Game{Options: GameOptions{Headless: true}, renderer: renderer, input: NewInput(), audio: NewNullAudioBackend(), lives: PLAYER_LIVES}
+	scene := NewPlayScene(g, 1)
+	if err := scene.Enter(); err != nil {
+		t.Fatal(err)
+	}
+	defer scene.Exit()
+
+	player := scene.player.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	truck := scene.Manager().GetEntityByName("truck1").GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	playerStart, truckStart := player.position, truck.position
+
+	g.input.HandleEvent(&sdl.KeyboardEvent{Type: sdl.KEYDOWN, Keysym: sdl.Keysym{Sym: sdl.GetKeyFromName("d")}})
+	// Drawing 800x600 in software is slow, so only every quarter second is
+	const steps, stepsPerFrame = 2 * UPDATE_RATE, UPDATE_RATE / 4
+	for step := 1; step <= steps; step++ {
+		scene.Update(FIXED_TIMESTEP)
+		g.input.BeginFrame()
+		if step%stepsPerFrame == 0 {
+			scene.Render(1)
+			if err := renderer.Present(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if renderer.FrameCount() != steps/stepsPerFrame {
+		t.Errorf("%d frames presented, want %d", renderer.FrameCount(), steps/stepsPerFrame)
+	}
+	if scene.finished || g.lives != PLAYER_LIVES {
+		t.Fatalf("level ended or the player died within %d steps", steps)
+	}
+	// 25 pixels a second to the right
+	if moved := player.position.Sub(playerStart); moved.X() < 45 || moved.X() > 55 || moved.Y() != 0 {
+		t.Errorf("player moved %v holding right, want about 50 pixels right", moved)
+	}
+	if truck.position == truckStart || truck.rotation == 0 {
+		t.Errorf("truck at %v turned %v degrees, want it on its way to the first waypoint", truck.position, truck.rotation)
+	}
+
+	// The camera follows the player, so the chopper is drawn on the map
+	frame := renderer.Frame()
+	center := scene.camera.WorldToScreen(player.Center())
+	if pixel := frame.RGBAAt(int(center.X()), int(center.Y())); pixel.A != 255 {
+		t.Errorf("pixel under the player is %v, want the chopper or the map", pixel)
+	}
+	opaque := 0
+	for y := range WINDOW_HEIGHT {
+		for x := range WINDOW_WIDTH {
+			if frame.RGBAAt(x, y).A == 255 {
+				opaque++
+			}
+		}
+	}
+	if opaque < WINDOW_WIDTH*WINDOW_HEIGHT/2 {
+		t.Errorf("only %d pixels drawn, want the map to cover most of the frame", opaque)
+	}
+}
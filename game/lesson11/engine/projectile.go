@@ -0,0 +1,166 @@
+package engine
+
+// ProjectilePool hands out projectile entities and takes them back instead of
+// creating and destroying one per shot. Pooled entities stay in the manager,
+// disabled, so their ids and components are reused.
+type ProjectilePool struct {
+	manager *EntityManager
+	texture Texture
+	width   int
+	height  int
+	tag     string
+	damage  int
+	free    []*Entity
+}
+
+func NewProjectilePool(manager *EntityManager, texture Texture, width, height int, tag string, damage, size int) *ProjectilePool {
+	pool := &ProjectilePool{manager: manager, texture: texture, width: width, height: height, tag: tag, damage: damage}
+	for range size {
+		pool.Release(pool.newProjectile())
+	}
+	return pool
+}
+
+func (p *ProjectilePool) newProjectile() *Entity {
+	projectile := p.manager.AddEntity("projectile", PROJECTILE_LAYER)
+	projectile.AddComponent(NewTransformComponent(Vec2{0, 0}, Vec2{0, 0}, p.width, p.height, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	projectile.AddComponent(NewSpriteComponent(p.texture), SPRITE_COMPONENT)
+	projectile.AddComponent(NewColliderComponent(p.tag, 0, 0, p.width, p.height), COLLIDER_COMPONENT)
+	projectile.AddComponent(&ProjectileComponent{pool: p}, PROJECTILE_COMPONENT)
+	projectile.AddComponent(NewDamageComponent(p.damage), DAMAGE_COMPONENT)
+	return projectile
+}
+
+// Acquire fires a projectile centered on position; it returns to the pool
+// once it has travelled scope pixels or Release is called.
+func (p *ProjectilePool) Acquire(shooter *Entity, position, velocity Vec2, scope float64) *Entity {
+	var projectile *Entity
+	if n := len(p.free); n > 0 {
+		projectile = p.free[n-1]
+		p.free = p.free[:n-1]
+	} else {
+		projectile = p.newProjectile()
+	}
+
+	origin := Vec2{position.X() - float64(p.width)/2, position.Y() - float64(p.height)/2}
+	transform := projectile.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	transform.Teleport(origin)
+	transform.velocity = velocity
+
+	component := projectile.GetComponent(PROJECTILE_COMPONENT).(*ProjectileComponent)
+	component.shooter = shooter
+	component.origin = origin
+	component.scope = scope
+
+	projectile.SetEnabled(true)
+
+	// Sync the collider now, the shot may be fired after its update pass
+	projectile.Update(0)
+	return projectile
+}
+
+func (p *ProjectilePool) Release(projectile *Entity) {
+	// A disabled projectile is already back in the pool
+	if !projectile.IsEnabled() {
+		return
+	}
+	projectile.SetEnabled(false)
+	p.free = append(p.free, projectile)
+}
+
+// ProjectileComponent is a pooled shot. It remembers who fired it so it
+// never hits its own shooter.
+type ProjectileComponent struct {
+	owner     *Entity
+	transform *TransformComponent
+	pool      *ProjectilePool
+	shooter   *Entity
+	origin    Vec2
+	scope     float64
+}
+
+func (c *ProjectileComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *ProjectileComponent) Initialize() {
+	c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+}
+
+func (c *ProjectileComponent) Update(deltaTime float64) {
+	if c.transform.position.Sub(c.origin).Length() > c.scope {
+		c.Release()
+	}
+}
+
+func (c *ProjectileComponent) Render(renderer Renderer) {}
+
+func (c *ProjectileComponent) Shooter() *Entity {
+	return c.shooter
+}
+
+func (c *ProjectileComponent) Release() {
+	c.shooter = nil
+	c.pool.Release(c.owner)
+}
+
+// firedBy reports whether projectile is a pooled shot fired by entity.
+func firedBy(projectile, entity *Entity) bool {
+	if !projectile.HasComponent(PROJECTILE_COMPONENT) {
+		return false
+	}
+	return projectile.GetComponent(PROJECTILE_COMPONENT).(*ProjectileComponent).shooter == entity
+}
+
+// WeaponComponent fires projectiles from the pool along direction, at most
+// fireRate times a second.
+type WeaponComponent struct {
+	owner     *Entity
+	transform *TransformComponent
+	pool      *ProjectilePool
+	speed     float64
+	scope     float64
+	fireRate  float64
+	cooldown  float64
+	direction Vec2
+}
+
+func NewWeaponComponent(pool *ProjectilePool, speed, scope, fireRate float64) *WeaponComponent {
+	return &WeaponComponent{pool: pool, speed: speed, scope: scope, fireRate: fireRate, direction: Vec2{0, 1}}
+}
+
+func (c *WeaponComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *WeaponComponent) Initialize() {
+	c.transform = c.owner.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+}
+
+func (c *WeaponComponent) Update(deltaTime float64) {
+	c.cooldown = max(c.cooldown-deltaTime, 0)
+}
+
+func (c *WeaponComponent) Render(renderer Renderer) {}
+
+// Aim points the weapon; direction doesn't need to be normalized.
+func (c *WeaponComponent) Aim(direction Vec2) {
+	if length := direction.Length(); length > 0 {
+		c.direction = Vec2{direction.X() / length, direction.Y() / length}
+	}
+}
+
+// Fire shoots unless the weapon is still cooling down from the last shot.
+func (c *WeaponComponent) Fire() *Entity {
+	if c.cooldown > 0 {
+		return nil
+	}
+	if c.fireRate > 0 {
+		c.cooldown = 1 / c.fireRate
+	}
+
+	velocity := Vec2{c.direction.X() * c.speed, c.direction.Y() * c.speed}
+	projectile := c.pool.Acquire(c.owner, c.transform.Center(), velocity, c.scope)
+	c.owner.manager.GetEventBus().Publish(ProjectileFiredEvent{c.owner, projectile})
+	return projectile
+}
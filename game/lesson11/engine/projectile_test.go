@@ -0,0 +1,107 @@
+package engine
+
+import "testing"
+
+func addShooter(manager *EntityManager, name, tag string, position Vec2) *Entity {
+	entity := manager.AddEntity(name, ENEMY_LAYER)
+	entity.AddComponent(NewTransformComponent(position, Vec2{}, 16, 16, Vec2{1, 1}), TRANSFORM_COMPONENT)
+	entity.AddComponent(NewColliderComponent(tag, 0, 0, 16, 16), COLLIDER_COMPONENT)
+	return entity
+}
+
+func TestProjectilePool(t *testing.T) {
+	manager := newTestManager()
+	pool := NewProjectilePool(manager, nil, 4, 4, "PROJECTILE", 1, 2)
+	if len(manager.GetEntities()) != 2 || len(pool.free) != 2 {
+		t.Fatalf("pool of 2 made %d entities, %d free", len(manager.GetEntities()), len(pool.free))
+	}
+
+	first := pool.Acquire(nil, Vec2{}, Vec2{}, 100)
+	second := pool.Acquire(nil, Vec2{}, Vec2{}, 100)
+	third := pool.Acquire(nil, Vec2{}, Vec2{}, 100)
+	if first == second || len(manager.GetEntities()) != 3 || !third.IsEnabled() {
+		t.Fatal("an empty pool didn't grow by one projectile")
+	}
+
+	// Releasing twice, e.g. by a hit and by running out of range in the same
+	// frame, mustn't hand the projectile out twice
+	pool.Release(second)
+	pool.Release(second)
+	if len(pool.free) != 1 || second.IsEnabled() {
+		t.Fatalf("%d free after releasing one projectile twice", len(pool.free))
+	}
+	if again := pool.Acquire(nil, Vec2{}, Vec2{}, 100); again != second || len(manager.GetEntities()) != 3 {
+		t.Error("the released projectile wasn't reused")
+	}
+
+	// Projectiles go back to the pool on their own out of range
+	fast := pool.Acquire(nil, Vec2{}, Vec2{100, 0}, 50)
+	manager.Update(1)
+	if fast.IsEnabled() || len(pool.free) != 1 {
+		t.Error("a projectile past its scope stayed out")
+	}
+}
+
+func TestWeaponFireRate(t *testing.T) {
+	manager := newTestManager()
+	pool := NewProjectilePool(manager, nil, 4, 4, "PROJECTILE", 1, 0)
+	shooter := addShooter(manager, "shooter", "PLAYER", Vec2{})
+	weapon := shooter.AddComponent(NewWeaponComponent(pool, 100, 1000, 2), WEAPON_COMPONENT).(*WeaponComponent)
+
+	shots := 0
+	for range 60 {
+		if weapon.Fire() != nil {
+			shots++
+		}
+		manager.Update(1.0 / 20)
+	}
+	// 3 seconds at 2 shots a second, counting the one at time 0
+	if shots != 6 && shots != 7 {
+		t.Errorf("fired %d shots in 3 seconds at 2 a second", shots)
+	}
+
+	weapon.Aim(Vec2{0, -3})
+	projectile := weapon.Fire()
+	for projectile == nil {
+		manager.Update(1.0 / 20)
+		projectile = weapon.Fire()
+	}
+	transform := projectile.GetComponent(TRANSFORM_COMPONENT).(*TransformComponent)
+	if transform.velocity != (Vec2{0, -100}) || transform.position != (Vec2{6, 6}) {
+		t.Errorf("shot from %v at %v, want from 6,6 at 0,-100", transform.position, transform.velocity)
+	}
+}
+
+func TestProjectileFilter(t *testing.T) {
+	manager := newTestManager()
+	enemies := NewProjectilePool(manager, nil, 4, 4, "PROJECTILE", 1, 0)
+	friendly := NewProjectilePool(manager, nil, 4, 4, "FRIENDLY_PROJECTILE", 1, 0)
+	shooter := addShooter(manager, "shooter", "ENEMY", Vec2{})
+	addShooter(manager, "other", "ENEMY", Vec2{4, 4})
+	addShooter(manager, "player", "PLAYER", Vec2{8, 8})
+
+	enemies.Acquire(shooter, Vec2{10, 10}, Vec2{}, 100)
+	friendly.Acquire(nil, Vec2{10, 10}, Vec2{}, 100)
+	manager.Update(0)
+	hits := map[string]CollisionType{}
+	for _, pair := range manager.CheckCollisions() {
+		if pair.that.HasComponent(PROJECTILE_COMPONENT) {
+			hits[pair.this.name+" "+pair.that.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent).colliderTag] = pair.collisionType
+		}
+	}
+
+	want := map[string]CollisionType{
+		"other PROJECTILE":            ENEMY_PROJECTILE_COLLISION,
+		"player PROJECTILE":           PLAYER_PROJECTILE_COLLISION,
+		"shooter FRIENDLY_PROJECTILE": ENEMY_FRIENDLY_PROJECTILE_COLLISION,
+		"other FRIENDLY_PROJECTILE":   ENEMY_FRIENDLY_PROJECTILE_COLLISION,
+	}
+	if len(hits) != len(want) {
+		t.Errorf("hits %v, want %v", hits, want)
+	}
+	for hit, collisionType := range want {
+		if hits[hit] != collisionType {
+			t.Errorf("%s: %v, want %v", hit, hits[hit], collisionType)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Texture is an image owned by the Renderer that created it; only that
+// renderer can draw it.
+type Texture interface {
+	Size() (int32, int32)
+	Destroy()
+}
+
+// Renderer is everything the engine draws with. Components never talk to a
+// graphics API directly, so the same game can draw to a window through SDL or
+// headless into an image.
+type Renderer interface {
+	LoadTexture(filename string) (Texture, error)
+	// ReloadTexture loads filename into an existing texture, so everything
+	// holding the texture draws the new image.
+	ReloadTexture(texture Texture, filename string) error
+	// CreateTexture makes a blank, transparent texture. Target textures can be
+	// drawn into with SetTarget, the others are filled with UpdateTexture.
+	CreateTexture(width, height int32, target bool) (Texture, error)
+	// UpdateTexture copies ARGB8888 pixels, pitch bytes per row, into rect.
+	UpdateTexture(texture Texture, rect sdl.Rect, pixels []byte, pitch int) error
+	// SetTarget draws into texture from now on, or into the frame if nil.
+	SetTarget(texture Texture) error
+	// DrawSprite draws source stretched over destination, turned angle
+	// degrees clockwise around pivot, relative to destination's top left
+	// corner, or around its center if pivot is nil.
+	DrawSprite(texture Texture, source, destination sdl.Rect, angle float64, pivot *sdl.Point, flip sdl.RendererFlip)
+	// DrawText draws white glyphs from texture tinted with color.
+	DrawText(texture Texture, source, destination sdl.Rect, color sdl.Color)
+	// DrawTinted draws texture with its colors and alpha multiplied by color.
+	DrawTinted(texture Texture, source, destination sdl.Rect, color sdl.Color)
+	// FillRect blends a rectangle of color over what is drawn.
+	FillRect(rect sdl.Rect, color sdl.Color)
+	// DrawRect blends a one pixel outline of rect.
+	DrawRect(rect sdl.Rect, color sdl.Color)
+	Clear(color sdl.Color)
+	Present() error
+	Destroy()
+}
+
+type sdlTexture struct {
+	texture *sdl.Texture
+	width   int32
+	height  int32
+}
+
+func (t *sdlTexture) Size() (int32, int32) {
+	return t.width, t.height
+}
+
+func (t *sdlTexture) Destroy() {
+	if t.texture != nil {
+		t.texture.Destroy()
+		t.texture = nil
+	}
+}
+
+// SDLRenderer draws to a window through an SDL renderer.
+type SDLRenderer struct {
+	renderer *sdl.Renderer
+}
+
+func NewSDLRenderer(renderer *sdl.Renderer) *SDLRenderer {
+	return &SDLRenderer{renderer: renderer}
+}
+
+func (r *SDLRenderer) LoadTexture(filename string) (Texture, error) {
+	surface, err := img.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer surface.Free()
+
+	texture, err := r.renderer.CreateTextureFromSurface(surface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create texture: %v", err)
+	}
+	return &sdlTexture{texture, surface.W, surface.H}, nil
+}
+
+func (r *SDLRenderer) ReloadTexture(texture Texture, filename string) error {
+	loaded, err := r.LoadTexture(filename)
+	if err != nil {
+		return err
+	}
+	t := texture.(*sdlTexture)
+	t.Destroy()
+	*t = *loaded.(*sdlTexture)
+	return nil
+}
+
+func (r *SDLRenderer) CreateTexture(width, height int32, target bool) (Texture, error) {
+	access := sdl.TEXTUREACCESS_STATIC
+	if target {
+		access = sdl.TEXTUREACCESS_TARGET
+	}
+	texture, err := r.renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, access, width, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create texture: %v", err)
+	}
+	texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+	return &sdlTexture{texture, width, height}, nil
+}
+
+func (r *SDLRenderer) UpdateTexture(texture Texture, rect sdl.Rect, pixels []byte, pitch int) error {
+	if len(pixels) == 0 {
+		return nil
+	}
+	return texture.(*sdlTexture).texture.Update(&rect, unsafe.Pointer(&pixels[0]), pitch)
+}
+
+func (r *SDLRenderer) SetTarget(texture Texture) error {
+	if texture == nil {
+		return r.renderer.SetRenderTarget(nil)
+	}
+	return r.renderer.SetRenderTarget(texture.(*sdlTexture).texture)
+}
+
+func (r *SDLRenderer) DrawSprite(texture Texture, source, destination sdl.Rect, angle float64, pivot *sdl.Point, flip sdl.RendererFlip) {
+	r.renderer.CopyEx(texture.(*sdlTexture).texture, &source, &destination, angle, pivot, flip)
+}
+
+func (r *SDLRenderer) DrawText(texture Texture, source, destination sdl.Rect, color sdl.Color) {
+	r.DrawTinted(texture, source, destination, color)
+}
+
+func (r *SDLRenderer) DrawTinted(texture Texture, source, destination sdl.Rect, color sdl.Color) {
+	t := texture.(*sdlTexture).texture
+	t.SetColorMod(color.R, color.G, color.B)
+	t.SetAlphaMod(color.A)
+	r.renderer.Copy(t, &source, &destination)
+	// The modulation sticks to the texture, which sprites may draw untinted
+	t.SetColorMod(255, 255, 255)
+	t.SetAlphaMod(255)
+}
+
+func (r *SDLRenderer) FillRect(rect sdl.Rect, color sdl.Color) {
+	r.renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	r.renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+	r.renderer.FillRect(&rect)
+}
+
+func (r *SDLRenderer) DrawRect(rect sdl.Rect, color sdl.Color) {
+	r.renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	r.renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+	r.renderer.DrawRect(&rect)
+}
+
+func (r *SDLRenderer) Clear(color sdl.Color) {
+	r.renderer.SetDrawColor(color.R, color.G, color.B, color.A)
+	r.renderer.Clear()
+}
+
+func (r *SDLRenderer) Present() error {
+	r.renderer.Present()
+	return nil
+}
+
+func (r *SDLRenderer) Destroy() {
+	r.renderer.Destroy()
+}
@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	MENU_FONT_SIZE  = 32
+	MENU_TEXT_SIZE  = 16
+	MENU_LINE_SPACE = 48
+)
+
+// Scene is one state of the game on the scene stack. Only the top scene is
+// updated, once per fixed step; overlay scenes are drawn over the scenes below
+// them, which stay frozen underneath. Render gets how far, from 0 to 1, the
+// frame is between the last fixed step and the next.
+type Scene interface {
+	Enter() error
+	Exit()
+	Update(deltaTime float64)
+	Render(alpha float64)
+	Manager() *EntityManager
+	IsOverlay() bool
+}
+
+// Reloadable scenes pick up asset files changed on disk while the game runs.
+type Reloadable interface {
+	Reload(files []string) error
+}
+
+// sceneScope is the entities and assets a scene owns. Leaving the scene
+// clears both, so nothing of it outlives the scene.
+type sceneScope struct {
+	manager      *EntityManager
+	assetManager *AssetManager
+}
+
+func (s *sceneScope) open(g *Game, camera *Camera) {
+	s.assetManager = NewAssetManager(g.renderer, g.audio)
+	s.manager = &EntityManager{renderer: g.renderer, input: g.input, camera: camera, assetManager: s.assetManager,
+		collisions: NewDefaultCollisionMatrix(), events: NewEventBus(), debug: g.debug}
+}
+
+func (s *sceneScope) close() {
+	if s.manager != nil {
+		s.manager.ClearData()
+		s.manager.DestroyInactiveEntities()
+	}
+	if s.assetManager != nil {
+		s.assetManager.ClearData()
+	}
+}
+
+func (s *sceneScope) Manager() *EntityManager {
+	return s.manager
+}
+
+// PushScene, PopScene and ReplaceScenes take effect after the current frame's
+// update, so a scene never exits while it is still running.
+func (g *Game) PushScene(scene Scene) {
+	g.sceneChanges = append(g.sceneChanges, func() error {
+		g.scenes = append(g.scenes, scene)
+		return scene.Enter()
+	})
+}
+
+func (g *Game) PopScene() {
+	g.sceneChanges = append(g.sceneChanges, func() error {
+		if n := len(g.scenes); n > 0 {
+			g.scenes[n-1].Exit()
+			g.scenes = g.scenes[:n-1]
+		}
+		return nil
+	})
+}
+
+// ReplaceScenes exits every scene on the stack, top first, then enters scene.
+func (g *Game) ReplaceScenes(scene Scene) {
+	g.sceneChanges = append(g.sceneChanges, func() error {
+		for i := len(g.scenes) - 1; i >= 0; i-- {
+			g.scenes[i].Exit()
+		}
+		clear(g.scenes)
+		g.scenes = append(g.scenes[:0], scene)
+		return scene.Enter()
+	})
+}
+
+func (g *Game) applySceneChanges() error {
+	for len(g.sceneChanges) > 0 {
+		change := g.sceneChanges[0]
+		g.sceneChanges = g.sceneChanges[1:]
+		if err := change(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Game) currentScene() Scene {
+	if n := len(g.scenes); n > 0 {
+		return g.scenes[n-1]
+	}
+	return nil
+}
+
+// visibleScenes is the top scene and the overlays' scenes beneath it, bottom
+// first.
+func (g *Game) visibleScenes() []Scene {
+	for i := len(g.scenes) - 1; i >= 0; i-- {
+		if !g.scenes[i].IsOverlay() {
+			return g.scenes[i:]
+		}
+	}
+	return g.scenes
+}
+
+func LevelExists(levelNumber int) bool {
+	_, err := os.Stat(LevelScript(levelNumber))
+	return err == nil
+}
+
+// MessageScene shows centered lines of text and hands the game to its input
+// handler every frame. The menu, pause, game over and level complete screens
+// are all message scenes.
+type MessageScene struct {
+	sceneScope
+	game        *Game
+	overlay     bool
+	title       string
+	lines       []string
+	handleInput func(input *Input)
+}
+
+func (s *MessageScene) Enter() error {
+	s.open(s.game, nil)
+	fontFile := filepath.Join(rootpath, "assets/fonts/charriot.ttf")
+	if err := s.assetManager.AddFont("title-font", fontFile, MENU_FONT_SIZE); err != nil {
+		return err
+	}
+	if err := s.assetManager.AddFont("text-font", fontFile, MENU_TEXT_SIZE); err != nil {
+		return err
+	}
+
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	y := WINDOW_HEIGHT/2 - (len(s.lines)+1)*MENU_LINE_SPACE/2
+	title := s.manager.AddEntity("title", UI_LAYER)
+	title.AddComponent(NewTextLabelComponent(WINDOW_WIDTH/2, y, s.title, "title-font", white), TEXT_LABEL_COMPONENT)
+	for i, line := range s.lines {
+		label := NewTextLabelComponent(WINDOW_WIDTH/2, y+(i+1)*MENU_LINE_SPACE, line, "text-font", white)
+		s.manager.AddEntity(fmt.Sprintf("line%d", i), UI_LAYER).AddComponent(label, TEXT_LABEL_COMPONENT)
+	}
+	for _, label := range s.manager.Query(TEXT_LABEL_COMPONENT) {
+		if err := label.GetComponent(TEXT_LABEL_COMPONENT).(*TextLabelComponent).SetAlignment(ALIGN_CENTER); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MessageScene) Exit() {
+	s.close()
+}
+
+func (s *MessageScene) Update(deltaTime float64) {
+	s.manager.Update(deltaTime)
+	s.manager.GetEventBus().Flush()
+	if s.handleInput != nil {
+		s.handleInput(s.game.input)
+	}
+}
+
+func (s *MessageScene) Render(alpha float64) {
+	if s.overlay {
+		s.game.renderer.FillRect(sdl.Rect{W: WINDOW_WIDTH, H: WINDOW_HEIGHT}, sdl.Color{A: 160})
+	}
+	s.manager.Render(alpha)
+}
+
+func (s *MessageScene) IsOverlay() bool {
+	return s.overlay
+}
+
+func NewMenuScene(g *Game) *MessageScene {
+	return &MessageScene{game: g, title: "Chopper", lines: []string{"Press Enter to start", "Press Q to quit"},
+		handleInput: func(input *Input) {
+			if input.ActionPressed("confirm") {
+				g.NewGame()
+			} else if input.ActionPressed("quit") || input.ActionPressed("pause") {
+				g.running = false
+			}
+		}}
+}
+
+func NewPauseScene(g *Game) *MessageScene {
+	return &MessageScene{game: g, overlay: true, title: "Paused", lines: []string{"Press Esc to resume", "Press Q for the menu"},
+		handleInput: func(input *Input) {
+			if input.ActionPressed("pause") {
+				g.PopScene()
+			} else if input.ActionPressed("quit") {
+				g.ReplaceScenes(NewMenuScene(g))
+			}
+		}}
+}
+
+func NewGameOverScene(g *Game) *MessageScene {
+	return &MessageScene{game: g, overlay: true, title: "Game Over",
+		lines: []string{fmt.Sprintf("Score: %d", g.score), "Press Enter for the menu"},
+		handleInput: func(input *Input) {
+			if input.ActionPressed("confirm") {
+				g.ReplaceScenes(NewMenuScene(g))
+			}
+		}}
+}
+
+// NewLevelCompleteScene goes on to the next level, or back to the menu once
+// there are no more level scripts.
+func NewLevelCompleteScene(g *Game, levelNumber int) *MessageScene {
+	if !LevelExists(levelNumber + 1) {
+		return &MessageScene{game: g, overlay: true, title: "You Win",
+			lines: []string{fmt.Sprintf("Score: %d", g.score), "Press Enter for the menu"},
+			handleInput: func(input *Input) {
+				if input.ActionPressed("confirm") {
+					g.ReplaceScenes(NewMenuScene(g))
+				}
+			}}
+	}
+	return &MessageScene{game: g, overlay: true, title: fmt.Sprintf("Level %d Complete", levelNumber),
+		lines: []string{fmt.Sprintf("Score: %d", g.score), "Press Enter to continue"},
+		handleInput: func(input *Input) {
+			if input.ActionPressed("confirm") {
+				g.ReplaceScenes(NewPlayScene(g, levelNumber+1))
+			}
+		}}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+type testScene struct {
+	sceneScope
+	name    string
+	overlay bool
+	log     *[]string
+}
+
+func (s *testScene) Enter() error             { *s.log = append(*s.log, "enter "+s.name); return nil }
+func (s *testScene) Exit()                    { *s.log = append(*s.log, "exit "+s.name) }
+func (s *testScene) Update(deltaTime float64) {}
+func (s *testScene) Render(alpha float64)     {}
+func (s *testScene) IsOverlay() bool          { return s.overlay }
+
+func TestSceneStack(t *testing.T) {
+	var log []string
+	g := &Game{}
+	play := &testScene{name: "play", log: &log}
+	pause := &testScene{name: "pause", overlay: true, log: &log}
+	menu := &testScene{name: "menu", log: &log}
+
+	g.PushScene(play)
+	g.PushScene(pause)
+	if len(log) != 0 {
+		t.Fatal("scene changes took effect before the end of the frame")
+	}
+	if err := g.applySceneChanges(); err != nil {
+		t.Fatal(err)
+	}
+	if g.currentScene() != pause || !slices.Equal(g.visibleScenes(), []Scene{play, pause}) {
+		t.Errorf("current %v, visible %v", g.currentScene(), g.visibleScenes())
+	}
+
+	g.ReplaceScenes(menu)
+	if err := g.applySceneChanges(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"enter play", "enter pause", "exit pause", "exit play", "enter menu"}
+	if !slices.Equal(log, want) || !slices.Equal(g.visibleScenes(), []Scene{menu}) {
+		t.Errorf("log %v, want %v", log, want)
+	}
+
+	g.PopScene()
+	g.PopScene()
+	if err := g.applySceneChanges(); err != nil || g.currentScene() != nil {
+		t.Errorf("popping past the bottom: %v, current %v", err, g.currentScene())
+	}
+}
@@ -0,0 +1,410 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const GLYPH_ATLAS_SIZE = 256
+
+type TextAlign int
+
+const (
+	ALIGN_LEFT TextAlign = iota
+	ALIGN_CENTER
+	ALIGN_RIGHT
+)
+
+func ParseTextAlign(name string) (TextAlign, error) {
+	switch name {
+	case "", "left":
+		return ALIGN_LEFT, nil
+	case "center":
+		return ALIGN_CENTER, nil
+	case "right":
+		return ALIGN_RIGHT, nil
+	}
+	return ALIGN_LEFT, fmt.Errorf("unknown text alignment %q", name)
+}
+
+type glyph struct {
+	source  sdl.Rect
+	advance int32
+	extent  int32
+}
+
+// GlyphAtlas renders each glyph of a font once, in white, into a shared
+// texture. Labels draw from it tinted with their color, so changing text
+// never allocates a texture.
+type GlyphAtlas struct {
+	font       *ttf.Font
+	renderer   Renderer
+	texture    Texture
+	size       int32
+	generation int
+	glyphs     map[rune]glyph
+	kerning    map[[2]rune]int32
+	cursorX    int32
+	cursorY    int32
+	rowHeight  int32
+}
+
+func NewGlyphAtlas(renderer Renderer, font *ttf.Font) (*GlyphAtlas, error) {
+	atlas := &GlyphAtlas{font: font, renderer: renderer, glyphs: make(map[rune]glyph), kerning: make(map[[2]rune]int32)}
+	if err := atlas.resize(GLYPH_ATLAS_SIZE); err != nil {
+		return nil, err
+	}
+	return atlas, nil
+}
+
+func (a *GlyphAtlas) LineHeight() int32 {
+	return int32(a.font.LineSkip())
+}
+
+func (a *GlyphAtlas) Destroy() {
+	if a.texture != nil {
+		a.texture.Destroy()
+		a.texture = nil
+	}
+}
+
+// reset switches to font, which the assets re-added under the atlas' name,
+// dropping the glyphs rendered in the old one.
+func (a *GlyphAtlas) reset(font *ttf.Font) error {
+	a.font = font
+	clear(a.glyphs)
+	clear(a.kerning)
+	return a.resize(a.size)
+}
+
+// resize starts over on a bigger texture and uploads the known glyphs again.
+func (a *GlyphAtlas) resize(size int32) error {
+	texture, err := a.renderer.CreateTexture(size, size, false)
+	if err != nil {
+		return fmt.Errorf("failed to create glyph atlas: %v", err)
+	}
+	a.Destroy()
+	a.texture, a.size = texture, size
+	a.generation++
+
+	known := make([]rune, 0, len(a.glyphs))
+	for r := range a.glyphs {
+		known = append(known, r)
+	}
+	clear(a.glyphs)
+	a.cursorX, a.cursorY, a.rowHeight = 0, 0, 0
+	for _, r := range known {
+		if _, err := a.Glyph(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *GlyphAtlas) Glyph(r rune) (glyph, error) {
+	if g, ok := a.glyphs[r]; ok {
+		return g, nil
+	}
+	metrics, err := a.font.GlyphMetrics(r)
+	if err != nil {
+		return glyph{}, fmt.Errorf("failed to measure glyph %q: %v", r, err)
+	}
+
+	surface, err := a.font.RenderUTF8Blended(string(r), sdl.Color{R: 255, G: 255, B: 255, A: 255})
+	if err != nil {
+		return glyph{}, fmt.Errorf("failed to render glyph %q: %v", r, err)
+	}
+	defer surface.Free()
+	converted, err := surface.ConvertFormat(sdl.PIXELFORMAT_ARGB8888, 0)
+	if err != nil {
+		return glyph{}, fmt.Errorf("failed to convert glyph %q: %v", r, err)
+	}
+	defer converted.Free()
+
+	width, height := converted.W, converted.H
+	if width > a.size || height > a.size {
+		return glyph{}, fmt.Errorf("glyph %q is larger than the atlas", r)
+	}
+	if a.cursorX+width > a.size {
+		a.cursorX, a.cursorY, a.rowHeight = 0, a.cursorY+a.rowHeight, 0
+	}
+	if a.cursorY+height > a.size {
+		if err := a.resize(a.size * 2); err != nil {
+			return glyph{}, err
+		}
+		return a.Glyph(r)
+	}
+
+	g := glyph{source: sdl.Rect{X: a.cursorX, Y: a.cursorY, W: width, H: height}, advance: int32(metrics.Advance),
+		extent: int32(max(metrics.Advance, metrics.MaxX))}
+	if err := a.renderer.UpdateTexture(a.texture, g.source, converted.Pixels(), int(converted.Pitch)); err != nil {
+		return glyph{}, fmt.Errorf("failed to upload glyph %q: %v", r, err)
+	}
+	a.cursorX += width
+	a.rowHeight = max(a.rowHeight, height)
+	a.glyphs[r] = g
+	return g, nil
+}
+
+// Kerning is how far r moves from where the advance of the glyph before it
+// puts it, negative for closer. SDL_ttf only kerns whole strings, so the pair
+// is measured and the glyphs' own widths taken off.
+func (a *GlyphAtlas) Kerning(previous, r rune) (int32, error) {
+	if !a.font.GetKerning() {
+		return 0, nil
+	}
+	pair := [2]rune{previous, r}
+	if kerning, ok := a.kerning[pair]; ok {
+		return kerning, nil
+	}
+
+	first, err := a.Glyph(previous)
+	if err != nil {
+		return 0, err
+	}
+	second, err := a.Glyph(r)
+	if err != nil {
+		return 0, err
+	}
+	width, _, err := a.font.SizeUTF8(string(pair[:]))
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure %q: %v", string(pair[:]), err)
+	}
+	kerning := int32(width) - first.advance - second.extent
+	a.kerning[pair] = kerning
+	return kerning, nil
+}
+
+// advance is where the pen goes from x once it has drawn r after previous,
+// and where r is drawn; previous is negative at the start of a line.
+func (a *GlyphAtlas) advance(x int32, previous, r rune) (int32, int32, glyph, error) {
+	g, err := a.Glyph(r)
+	if err != nil {
+		return 0, 0, glyph{}, err
+	}
+	if previous >= 0 {
+		kerning, err := a.Kerning(previous, r)
+		if err != nil {
+			return 0, 0, glyph{}, err
+		}
+		x += kerning
+	}
+	return x + g.advance, x, g, nil
+}
+
+// Measure returns the width of a line of text, the sum of its glyphs'
+// kerned advances.
+func (a *GlyphAtlas) Measure(text string) (int32, error) {
+	var width int32
+	previous := rune(-1)
+	for _, r := range text {
+		next, _, _, err := a.advance(width, previous, r)
+		if err != nil {
+			return 0, err
+		}
+		width, previous = next, r
+	}
+	return width, nil
+}
+
+// Wrap breaks text into lines at newlines and, when wrapWidth is positive,
+// between words so no line is wider than wrapWidth. A single word wider than
+// wrapWidth gets a line of its own.
+func (a *GlyphAtlas) Wrap(text string, wrapWidth int32) ([]string, error) {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		if wrapWidth <= 0 {
+			lines = append(lines, paragraph)
+			continue
+		}
+
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			width, err := a.Measure(candidate)
+			if err != nil {
+				return nil, err
+			}
+			if width > wrapWidth && line != "" {
+				lines = append(lines, line)
+				candidate = word
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+type placedGlyph struct {
+	r           rune
+	source      sdl.Rect
+	destination sdl.Rect
+}
+
+type TextLabelComponent struct {
+	owner      *Entity
+	position   sdl.Rect
+	text       string
+	fontFamily string
+	color      sdl.Color
+	align      TextAlign
+	wrapWidth  int32
+	bounds     sdl.Rect
+	atlas      *GlyphAtlas
+	generation int
+	glyphs     []placedGlyph
+	err        error
+}
+
+func NewTextLabelComponent(x, y int, text, fontFamily string, color sdl.Color) *TextLabelComponent {
+	textLabel := &TextLabelComponent{text: text, fontFamily: fontFamily, color: color}
+	textLabel.position.X = int32(x)
+	textLabel.position.Y = int32(y)
+
+	return textLabel
+}
+
+func (c *TextLabelComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *TextLabelComponent) Initialize() {
+	c.err = c.layout()
+}
+
+// Err reports why the label couldn't be laid out when it was added.
+func (c *TextLabelComponent) Err() error {
+	return c.err
+}
+
+func (c *TextLabelComponent) Text() string {
+	return c.text
+}
+
+// SetText changes the label. Glyphs come from the font's atlas, so this is
+// cheap enough to call every frame.
+func (c *TextLabelComponent) SetText(text string) error {
+	if text == c.text && c.atlas != nil {
+		return nil
+	}
+	c.text = text
+	return c.layout()
+}
+
+// SetAlignment aligns lines within the wrap width, or relative to the label's
+// x position when the label doesn't wrap: left of it for ALIGN_RIGHT and
+// around it for ALIGN_CENTER.
+func (c *TextLabelComponent) SetAlignment(align TextAlign) error {
+	c.align = align
+	return c.layout()
+}
+
+// SetWrapWidth wraps lines wider than width pixels; 0 turns wrapping off.
+func (c *TextLabelComponent) SetWrapWidth(width int) error {
+	c.wrapWidth = int32(width)
+	return c.layout()
+}
+
+// Bounds is the rectangle the laid out text covers on screen.
+func (c *TextLabelComponent) Bounds() sdl.Rect {
+	return c.bounds
+}
+
+func (c *TextLabelComponent) layout() error {
+	if c.owner == nil {
+		return nil
+	}
+
+	atlas, err := c.owner.manager.assetManager.GetGlyphAtlas(c.fontFamily)
+	if err != nil {
+		return err
+	}
+	// Caching every glyph up front means the atlas can't grow, and move the
+	// glyphs, halfway through the layout
+	if _, err := atlas.Measure(c.text); err != nil {
+		return err
+	}
+	lines, err := atlas.Wrap(c.text, c.wrapWidth)
+	if err != nil {
+		return err
+	}
+
+	c.atlas, c.generation = atlas, atlas.generation
+	c.glyphs = c.glyphs[:0]
+	lineHeight := atlas.LineHeight()
+	var left, right int32
+	for i, line := range lines {
+		width, err := atlas.Measure(line)
+		if err != nil {
+			return err
+		}
+
+		x := c.position.X
+		switch {
+		case c.align == ALIGN_CENTER && c.wrapWidth > 0:
+			x += (c.wrapWidth - width) / 2
+		case c.align == ALIGN_CENTER:
+			x -= width / 2
+		case c.align == ALIGN_RIGHT && c.wrapWidth > 0:
+			x += c.wrapWidth - width
+		case c.align == ALIGN_RIGHT:
+			x -= width
+		}
+		if i == 0 || x < left {
+			left = x
+		}
+		if i == 0 || x+width > right {
+			right = x + width
+		}
+
+		y := c.position.Y + int32(i)*lineHeight
+		previous := rune(-1)
+		for _, r := range line {
+			next, at, g, err := atlas.advance(x, previous, r)
+			if err != nil {
+				return err
+			}
+			c.glyphs = append(c.glyphs, placedGlyph{r, g.source, sdl.Rect{X: at, Y: y, W: g.source.W, H: g.source.H}})
+			x, previous = next, r
+		}
+	}
+
+	c.bounds = sdl.Rect{X: left, Y: c.position.Y, W: right - left, H: int32(len(lines)) * lineHeight}
+	return nil
+}
+
+func (c *TextLabelComponent) Update(deltaTime float64) {}
+
+// sync catches up with the atlas. Growing it moves glyphs within the texture
+// but not on screen; a new font changes their sizes, so the text is laid out
+// again.
+func (c *TextLabelComponent) sync() error {
+	if c.atlas == nil || c.generation == c.atlas.generation {
+		return nil
+	}
+	for i, g := range c.glyphs {
+		moved, ok := c.atlas.glyphs[g.r]
+		if !ok {
+			return c.layout()
+		}
+		c.glyphs[i].source = moved.source
+	}
+	c.generation = c.atlas.generation
+	return nil
+}
+
+func (c *TextLabelComponent) Render(renderer Renderer) {
+	if c.sync() != nil || c.atlas == nil {
+		return
+	}
+	for _, g := range c.glyphs {
+		renderer.DrawText(c.atlas.texture, g.source, g.destination, c.color)
+	}
+}
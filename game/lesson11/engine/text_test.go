@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const TEST_FONT = "../../assets/fonts/arial.ttf"
+
+func newTextManager(t *testing.T, fontSize int) (*ImageRenderer, *EntityManager) {
+	t.Helper()
+	if err := ttf.Init(); err != nil {
+		t.Fatal(err)
+	}
+	renderer, manager := newHeadlessManager(t, 320, 240)
+	if err := manager.assetManager.AddFont("arial", TEST_FONT, fontSize); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		manager.assetManager.ClearData()
+		ttf.Quit()
+	})
+	return renderer, manager
+}
+
+func addLabel(t *testing.T, manager *EntityManager, text string, align TextAlign, wrapWidth int) *TextLabelComponent {
+	t.Helper()
+	label := NewTextLabelComponent(100, 20, text, "arial", sdl.Color{R: 255, G: 255, B: 255, A: 255})
+	label.align, label.wrapWidth = align, int32(wrapWidth)
+	manager.AddEntity("label", UI_LAYER).AddComponent(label, TEXT_LABEL_COMPONENT)
+	if err := label.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return label
+}
+
+func TestWrap(t *testing.T) {
+	_, manager := newTextManager(t, 16)
+	atlas, err := manager.assetManager.GetGlyphAtlas("arial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneTwo, err := atlas.Measure("one two")
+	if err != nil {
+		t.Fatal(err)
+	}
+	long, err := atlas.Measure("long")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		text      string
+		wrapWidth int32
+		lines     []string
+	}{
+		{"one two three", oneTwo, []string{"one two", "three"}},
+		{"one two three", 0, []string{"one two three"}},
+		{"one\ntwo", 0, []string{"one", "two"}},
+		{"one\n\ntwo", oneTwo, []string{"one", "", "two"}},
+		{"extraordinarily long", long, []string{"extraordinarily", "long"}},
+	} {
+		lines, err := atlas.Wrap(test.text, test.wrapWidth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(lines, test.lines) {
+			t.Errorf("%q wrapped at %d: %q, want %q", test.text, test.wrapWidth, lines, test.lines)
+		}
+	}
+}
+
+func TestTextAlignment(t *testing.T) {
+	_, manager := newTextManager(t, 16)
+	label := addLabel(t, manager, "Score", ALIGN_RIGHT, 200)
+	bounds := label.Bounds()
+	if bounds.X+bounds.W != 300 || bounds.Y != 20 || bounds.H != int32(manager.assetManager.GetFont("arial").LineSkip()) {
+		t.Errorf("right aligned in 200 pixels: %v", bounds)
+	}
+
+	for _, test := range []struct {
+		align     TextAlign
+		wrapWidth int
+		left      int32
+	}{
+		{ALIGN_LEFT, 200, 100},
+		{ALIGN_CENTER, 200, 100 + (200-bounds.W)/2},
+		{ALIGN_CENTER, 0, 100 - bounds.W/2},
+		{ALIGN_RIGHT, 0, 100 - bounds.W},
+	} {
+		if err := label.SetWrapWidth(test.wrapWidth); err != nil {
+			t.Fatal(err)
+		}
+		if err := label.SetAlignment(test.align); err != nil {
+			t.Fatal(err)
+		}
+		if got := label.Bounds(); got.X != test.left || got.W != bounds.W {
+			t.Errorf("alignment %v wrapping at %d: %v, want left edge %d", test.align, test.wrapWidth, got, test.left)
+		}
+	}
+
+	unknown := NewTextLabelComponent(0, 0, "?", "missing", sdl.Color{})
+	manager.AddEntity("unknown", UI_LAYER).AddComponent(unknown, TEXT_LABEL_COMPONENT)
+	if unknown.Err() == nil {
+		t.Error("a label in an unknown font laid out")
+	}
+}
+
+func TestGlyphAtlasGrowth(t *testing.T) {
+	renderer, manager := newTextManager(t, 48)
+	label := addLabel(t, manager, "AB", ALIGN_LEFT, 0)
+	atlas := label.atlas
+	before := label.glyphs[1].source
+
+	for r := '!'; r <= '~'; r++ {
+		if _, err := atlas.Glyph(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if atlas.size == GLYPH_ATLAS_SIZE || atlas.generation == label.generation {
+		t.Fatalf("atlas stayed %d pixels for every printable ASCII glyph at 48 points", atlas.size)
+	}
+
+	// Drawing catches the label up with where its glyphs moved
+	label.Render(renderer)
+	for i, g := range label.glyphs {
+		if g.source != atlas.glyphs[g.r].source {
+			t.Errorf("glyph %d drawn from %v, the atlas has it at %v", i, g.source, atlas.glyphs[g.r].source)
+		}
+	}
+	if label.glyphs[1].source.W != before.W {
+		t.Error("growing the atlas resized a glyph")
+	}
+
+	// Adding the font again at another size lays the label out anew
+	width := label.Bounds().W
+	if err := manager.assetManager.AddFont("arial", TEST_FONT, 24); err != nil {
+		t.Fatal(err)
+	}
+	label.Render(renderer)
+	if got := label.Bounds().W; got >= width {
+		t.Errorf("label %d pixels wide in half the size, was %d", got, width)
+	}
+}
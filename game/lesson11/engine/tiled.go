@@ -0,0 +1,817 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	TILED_FLIPPED_HORIZONTALLY uint32 = 0x80000000
+	TILED_FLIPPED_VERTICALLY   uint32 = 0x40000000
+	TILED_FLIPPED_DIAGONALLY   uint32 = 0x20000000
+	TILED_ROTATED_HEXAGONAL    uint32 = 0x10000000
+	TILED_GID_MASK                    = ^(TILED_FLIPPED_HORIZONTALLY | TILED_FLIPPED_VERTICALLY | TILED_FLIPPED_DIAGONALLY | TILED_ROTATED_HEXAGONAL)
+)
+
+// TiledMap is the engine's view of a Tiled map, whichever of the .tmx (XML)
+// or .tmj (JSON) formats it was read from.
+type TiledMap struct {
+	width      int
+	height     int
+	tileWidth  int
+	tileHeight int
+	tilesets   []*TiledTileset
+	layers     []*TiledLayer
+}
+
+type TiledTileset struct {
+	firstGid   uint32
+	name       string
+	image      string
+	tileWidth  int
+	tileHeight int
+	tileCount  int
+	columns    int
+	margin     int
+	spacing    int
+	tiles      map[uint32]*TiledTile
+}
+
+type TiledTile struct {
+	class      string
+	properties map[string]string
+	shapes     []TiledObject
+}
+
+type TiledLayer struct {
+	name       string
+	kind       string
+	visible    bool
+	data       []uint32
+	objects    []TiledObject
+	properties map[string]string
+}
+
+type TiledObject struct {
+	id         int
+	name       string
+	class      string
+	x          float64
+	y          float64
+	width      float64
+	height     float64
+	properties map[string]string
+}
+
+func (o TiledObject) Name() string {
+	return o.name
+}
+
+func (o TiledObject) Class() string {
+	return o.class
+}
+
+func (o TiledObject) Property(name string) string {
+	return o.properties[name]
+}
+
+func (m *TiledMap) PixelSize() (int, int) {
+	return m.width * m.tileWidth, m.height * m.tileHeight
+}
+
+func (m *TiledMap) tilesetFor(gid uint32) *TiledTileset {
+	var found *TiledTileset
+	for _, tileset := range m.tilesets {
+		if tileset.firstGid <= gid && (found == nil || tileset.firstGid > found.firstGid) {
+			found = tileset
+		}
+	}
+	return found
+}
+
+func LoadTiledMap(filename string) (*TiledMap, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".tmx":
+		return loadTMX(filename)
+	case ".tmj", ".json":
+		return loadTMJ(filename)
+	}
+	return nil, fmt.Errorf("unsupported Tiled map format %s", filename)
+}
+
+/* TMX (XML) */
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+type tmxObject struct {
+	Id         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Width      float64       `xml:"width,attr"`
+	Height     float64       `xml:"height,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxTile struct {
+	Id         uint32        `xml:"id,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Objects    []tmxObject   `xml:"objectgroup>object"`
+}
+
+type tmxTileset struct {
+	FirstGid   uint32    `xml:"firstgid,attr"`
+	Source     string    `xml:"source,attr"`
+	Name       string    `xml:"name,attr"`
+	TileWidth  int       `xml:"tilewidth,attr"`
+	TileHeight int       `xml:"tileheight,attr"`
+	TileCount  int       `xml:"tilecount,attr"`
+	Columns    int       `xml:"columns,attr"`
+	Margin     int       `xml:"margin,attr"`
+	Spacing    int       `xml:"spacing,attr"`
+	Image      tmxImage  `xml:"image"`
+	Tiles      []tmxTile `xml:"tile"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+}
+
+type tmxData struct {
+	Encoding    string `xml:"encoding,attr"`
+	Compression string `xml:"compression,attr"`
+	Text        string `xml:",chardata"`
+	Tiles       []struct {
+		Gid uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+}
+
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Data       tmxData       `xml:"data"`
+	Objects    []tmxObject   `xml:"object"`
+	Layers     []tmxLayer    `xml:",any"`
+}
+
+type tmxMap struct {
+	Width      int          `xml:"width,attr"`
+	Height     int          `xml:"height,attr"`
+	TileWidth  int          `xml:"tilewidth,attr"`
+	TileHeight int          `xml:"tileheight,attr"`
+	Tilesets   []tmxTileset `xml:"tileset"`
+	Layers     []tmxLayer   `xml:",any"`
+}
+
+func loadTMX(filename string) (*TiledMap, error) {
+	var raw tmxMap
+	if err := decodeXMLFile(filename, &raw); err != nil {
+		return nil, err
+	}
+
+	tiledMap := &TiledMap{width: raw.Width, height: raw.Height, tileWidth: raw.TileWidth, tileHeight: raw.TileHeight}
+	for _, rawTileset := range raw.Tilesets {
+		dir := filepath.Dir(filename)
+		if rawTileset.Source != "" {
+			source := filepath.Join(dir, rawTileset.Source)
+			firstGid := rawTileset.FirstGid
+			if err := decodeXMLFile(source, &rawTileset); err != nil {
+				return nil, err
+			}
+			rawTileset.FirstGid = firstGid
+			dir = filepath.Dir(source)
+		}
+		tiledMap.tilesets = append(tiledMap.tilesets, rawTileset.convert(dir))
+	}
+
+	if err := tiledMap.addTMXLayers(raw.Layers, raw.Width*raw.Height); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", filename, err)
+	}
+	return tiledMap, nil
+}
+
+func decodeXMLFile(filename string, v any) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file %v", err)
+	}
+	defer file.Close()
+	if err := xml.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	return nil
+}
+
+func (t tmxTileset) convert(dir string) *TiledTileset {
+	tileset := &TiledTileset{
+		firstGid: t.FirstGid, name: t.Name, image: tiledPath(dir, t.Image.Source),
+		tileWidth: t.TileWidth, tileHeight: t.TileHeight, tileCount: t.TileCount,
+		columns: t.Columns, margin: t.Margin, spacing: t.Spacing,
+		tiles: make(map[uint32]*TiledTile),
+	}
+	for _, rawTile := range t.Tiles {
+		tile := &TiledTile{class: firstNonEmpty(rawTile.Class, rawTile.Type), properties: tmxProperties(rawTile.Properties)}
+		for _, object := range rawTile.Objects {
+			tile.shapes = append(tile.shapes, object.convert())
+		}
+		tileset.tiles[rawTile.Id] = tile
+	}
+	return tileset
+}
+
+func (o tmxObject) convert() TiledObject {
+	return TiledObject{id: o.Id, name: o.Name, class: firstNonEmpty(o.Class, o.Type),
+		x: o.X, y: o.Y, width: o.Width, height: o.Height, properties: tmxProperties(o.Properties)}
+}
+
+func tmxProperties(properties []tmxProperty) map[string]string {
+	values := make(map[string]string)
+	for _, property := range properties {
+		// Multi-line string properties keep their value in the element text
+		values[property.Name] = firstNonEmpty(property.Value, property.Text)
+	}
+	return values
+}
+
+func (m *TiledMap) addTMXLayers(layers []tmxLayer, cellCount int) error {
+	for _, rawLayer := range layers {
+		layer := &TiledLayer{name: rawLayer.Name, visible: rawLayer.Visible == nil || *rawLayer.Visible != 0, properties: tmxProperties(rawLayer.Properties)}
+		switch rawLayer.XMLName.Local {
+		case "layer":
+			layer.kind = "tilelayer"
+			data, err := decodeTileData(rawLayer.Data.Encoding, rawLayer.Data.Compression, rawLayer.Data.Text)
+			if err != nil {
+				return err
+			}
+			for _, tile := range rawLayer.Data.Tiles {
+				data = append(data, tile.Gid)
+			}
+			if len(data) != cellCount {
+				return fmt.Errorf("layer %s has %d tiles, expected %d", rawLayer.Name, len(data), cellCount)
+			}
+			layer.data = data
+		case "objectgroup":
+			layer.kind = "objectgroup"
+			for _, object := range rawLayer.Objects {
+				layer.objects = append(layer.objects, object.convert())
+			}
+		case "group":
+			if err := m.addTMXLayers(rawLayer.Layers, cellCount); err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+		m.layers = append(m.layers, layer)
+	}
+	return nil
+}
+
+/* TMJ (JSON) */
+
+type tmjProperty struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+type tmjObject struct {
+	Id         int           `json:"id"`
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Class      string        `json:"class"`
+	X          float64       `json:"x"`
+	Y          float64       `json:"y"`
+	Width      float64       `json:"width"`
+	Height     float64       `json:"height"`
+	Properties []tmjProperty `json:"properties"`
+}
+
+type tmjTileset struct {
+	FirstGid   uint32 `json:"firstgid"`
+	Source     string `json:"source"`
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	TileWidth  int    `json:"tilewidth"`
+	TileHeight int    `json:"tileheight"`
+	TileCount  int    `json:"tilecount"`
+	Columns    int    `json:"columns"`
+	Margin     int    `json:"margin"`
+	Spacing    int    `json:"spacing"`
+	Tiles      []struct {
+		Id          uint32        `json:"id"`
+		Type        string        `json:"type"`
+		Class       string        `json:"class"`
+		Properties  []tmjProperty `json:"properties"`
+		ObjectGroup *struct {
+			Objects []tmjObject `json:"objects"`
+		} `json:"objectgroup"`
+	} `json:"tiles"`
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Visible     *bool           `json:"visible"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Objects     []tmjObject     `json:"objects"`
+	Layers      []tmjLayer      `json:"layers"`
+	Properties  []tmjProperty   `json:"properties"`
+}
+
+type tmjMap struct {
+	Width      int          `json:"width"`
+	Height     int          `json:"height"`
+	TileWidth  int          `json:"tilewidth"`
+	TileHeight int          `json:"tileheight"`
+	Tilesets   []tmjTileset `json:"tilesets"`
+	Layers     []tmjLayer   `json:"layers"`
+}
+
+func loadTMJ(filename string) (*TiledMap, error) {
+	var raw tmjMap
+	if err := decodeJSONFile(filename, &raw); err != nil {
+		return nil, err
+	}
+
+	tiledMap := &TiledMap{width: raw.Width, height: raw.Height, tileWidth: raw.TileWidth, tileHeight: raw.TileHeight}
+	for _, rawTileset := range raw.Tilesets {
+		dir := filepath.Dir(filename)
+		if rawTileset.Source != "" {
+			source := filepath.Join(dir, rawTileset.Source)
+			firstGid := rawTileset.FirstGid
+			if err := decodeJSONFile(source, &rawTileset); err != nil {
+				return nil, err
+			}
+			rawTileset.FirstGid = firstGid
+			dir = filepath.Dir(source)
+		}
+		tiledMap.tilesets = append(tiledMap.tilesets, rawTileset.convert(dir))
+	}
+
+	if err := tiledMap.addTMJLayers(raw.Layers, raw.Width*raw.Height); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", filename, err)
+	}
+	return tiledMap, nil
+}
+
+func decodeJSONFile(filename string, v any) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", filename, err)
+	}
+	return nil
+}
+
+func (t tmjTileset) convert(dir string) *TiledTileset {
+	tileset := &TiledTileset{
+		firstGid: t.FirstGid, name: t.Name, image: tiledPath(dir, t.Image),
+		tileWidth: t.TileWidth, tileHeight: t.TileHeight, tileCount: t.TileCount,
+		columns: t.Columns, margin: t.Margin, spacing: t.Spacing,
+		tiles: make(map[uint32]*TiledTile),
+	}
+	for _, rawTile := range t.Tiles {
+		tile := &TiledTile{class: firstNonEmpty(rawTile.Class, rawTile.Type), properties: tmjProperties(rawTile.Properties)}
+		if rawTile.ObjectGroup != nil {
+			for _, object := range rawTile.ObjectGroup.Objects {
+				tile.shapes = append(tile.shapes, object.convert())
+			}
+		}
+		tileset.tiles[rawTile.Id] = tile
+	}
+	return tileset
+}
+
+func (o tmjObject) convert() TiledObject {
+	return TiledObject{id: o.Id, name: o.Name, class: firstNonEmpty(o.Class, o.Type),
+		x: o.X, y: o.Y, width: o.Width, height: o.Height, properties: tmjProperties(o.Properties)}
+}
+
+func tmjProperties(properties []tmjProperty) map[string]string {
+	values := make(map[string]string)
+	for _, property := range properties {
+		values[property.Name] = fmt.Sprint(property.Value)
+	}
+	return values
+}
+
+func (m *TiledMap) addTMJLayers(layers []tmjLayer, cellCount int) error {
+	for _, rawLayer := range layers {
+		layer := &TiledLayer{name: rawLayer.Name, kind: rawLayer.Type, visible: rawLayer.Visible == nil || *rawLayer.Visible, properties: tmjProperties(rawLayer.Properties)}
+		switch rawLayer.Type {
+		case "tilelayer":
+			var data []uint32
+			if rawLayer.Encoding == "base64" {
+				var text string
+				if err := json.Unmarshal(rawLayer.Data, &text); err != nil {
+					return err
+				}
+				decoded, err := decodeTileData(rawLayer.Encoding, rawLayer.Compression, text)
+				if err != nil {
+					return err
+				}
+				data = decoded
+			} else if err := json.Unmarshal(rawLayer.Data, &data); err != nil {
+				return err
+			}
+			if len(data) != cellCount {
+				return fmt.Errorf("layer %s has %d tiles, expected %d", rawLayer.Name, len(data), cellCount)
+			}
+			layer.data = data
+		case "objectgroup":
+			for _, object := range rawLayer.Objects {
+				layer.objects = append(layer.objects, object.convert())
+			}
+		case "group":
+			if err := m.addTMJLayers(rawLayer.Layers, cellCount); err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+		m.layers = append(m.layers, layer)
+	}
+	return nil
+}
+
+func decodeTileData(encoding, compression, text string) ([]uint32, error) {
+	text = strings.TrimSpace(text)
+	switch encoding {
+	case "":
+		return nil, nil
+	case "csv":
+		var data []uint32
+		for _, field := range strings.Split(text, ",") {
+			gid, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tile %v", err)
+			}
+			data = append(data, uint32(gid))
+		}
+		return data, nil
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tile data %v", err)
+		}
+
+		var reader io.Reader = bytes.NewReader(raw)
+		switch compression {
+		case "":
+		case "zlib":
+			if reader, err = zlib.NewReader(reader); err != nil {
+				return nil, err
+			}
+		case "gzip":
+			if reader, err = gzip.NewReader(reader); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported tile data compression %s", compression)
+		}
+
+		raw, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress tile data %v", err)
+		}
+		data := make([]uint32, len(raw)/4)
+		for i := range data {
+			data[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unsupported tile data encoding %s", encoding)
+}
+
+// tiledPath resolves a path relative to the file that referenced it, keeping
+// empty paths empty (image collection tilesets have no single image).
+func tiledPath(dir, path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(dir, path)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+/* Importing into the engine */
+
+type ObjectSpawner func(object TiledObject, position Vec2, layer LayerType) (*Entity, error)
+
+type TiledImporter struct {
+	manager      *EntityManager
+	assetManager *AssetManager
+	scale        int
+	spawners     map[string]ObjectSpawner
+	tilemaps     map[LayerType]*TilemapComponent
+}
+
+func NewTiledImporter(manager *EntityManager, assetManager *AssetManager, scale int) *TiledImporter {
+	return &TiledImporter{manager: manager, assetManager: assetManager, scale: scale, spawners: make(map[string]ObjectSpawner)}
+}
+
+// RegisterSpawner makes objects of the given Tiled class spawn through fn
+// instead of the property driven default.
+func (i *TiledImporter) RegisterSpawner(class string, fn ObjectSpawner) {
+	i.spawners[class] = fn
+}
+
+func (i *TiledImporter) Import(filename string) (*TiledMap, error) {
+	tiledMap, err := LoadTiledMap(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]bool)
+	for _, tileset := range tiledMap.tilesets {
+		if tileset.image == "" {
+			return nil, fmt.Errorf("tileset %s has no image, image collections are not supported", tileset.name)
+		}
+		// Tilesets cut from the same image share its texture
+		if textureId := tiledTextureId(tileset); !loaded[textureId] {
+			if err := i.assetManager.AddTexture(textureId, tileset.image); err != nil {
+				return nil, err
+			}
+			loaded[textureId] = true
+		}
+	}
+
+	i.tilemaps = make(map[LayerType]*TilemapComponent)
+	for _, layer := range tiledMap.layers {
+		// A walkability layer is never drawn, so it is usually hidden in Tiled
+		if layer.kind == "tilelayer" && layer.properties["walkability"] == "true" {
+			i.addWalkability(tiledMap, layer)
+			continue
+		}
+		if !layer.visible {
+			continue
+		}
+		switch layer.kind {
+		case "tilelayer":
+			engineLayer, err := parseLayerType(layer.properties["layer"], TILEMAP_LAYER)
+			if err != nil {
+				return nil, err
+			}
+			if err := i.addTiles(tiledMap, layer, i.tilemapFor(tiledMap, engineLayer)); err != nil {
+				return nil, err
+			}
+		case "objectgroup":
+			engineLayer, err := parseLayerType(layer.properties["layer"], OBSTACLE_LAYER)
+			if err != nil {
+				return nil, err
+			}
+			if err := i.spawnObjects(layer, engineLayer); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tiledMap, nil
+}
+
+// tilemapFor is the tilemap of an engine layer. Tile layers sharing an engine
+// layer are stacked into one tilemap.
+func (i *TiledImporter) tilemapFor(tiledMap *TiledMap, layer LayerType) *TilemapComponent {
+	tilemap, ok := i.tilemaps[layer]
+	if !ok {
+		tilemap = NewTilemapComponent(tiledMap.width, tiledMap.height, tiledMap.tileWidth, tiledMap.tileHeight, i.scale)
+		i.manager.AddEntity("tilemap", layer).AddComponent(tilemap, TILEMAP_COMPONENT)
+		i.tilemaps[layer] = tilemap
+	}
+	return tilemap
+}
+
+// applyWalkability marks a cell from its tile's "walkable" and "cost"
+// properties, in the TILEMAP_LAYER tilemap paths are searched on.
+func (i *TiledImporter) applyWalkability(tiledMap *TiledMap, index int, tile *TiledTile, walkable bool) {
+	if tile != nil {
+		if value, ok := tile.properties["walkable"]; ok {
+			walkable = value != "false"
+		}
+	}
+	navigation := i.tilemapFor(tiledMap, TILEMAP_LAYER)
+	x, y := index%tiledMap.width, index/tiledMap.width
+	if !walkable {
+		navigation.SetWalkable(x, y, false)
+	}
+	if tile != nil {
+		if cost, err := strconv.ParseFloat(tile.properties["cost"], 64); err == nil {
+			navigation.SetCost(x, y, max(cost, navigation.Cost(x, y)))
+		}
+	}
+}
+
+// addWalkability reads a layer with the "walkability" property: its tiles
+// are not drawn, they block their cells unless their properties say
+// otherwise.
+func (i *TiledImporter) addWalkability(tiledMap *TiledMap, layer *TiledLayer) {
+	for index, rawGid := range layer.data {
+		gid := rawGid & TILED_GID_MASK
+		if gid == 0 {
+			continue
+		}
+		var tile *TiledTile
+		if tileset := tiledMap.tilesetFor(gid); tileset != nil {
+			tile = tileset.tiles[gid-tileset.firstGid]
+		}
+		i.applyWalkability(tiledMap, index, tile, false)
+	}
+}
+
+// tiledTextureId keys a tileset's texture by its image, since tileset names
+// needn't be unique.
+func tiledTextureId(tileset *TiledTileset) string {
+	return "tiled-" + tileset.image
+}
+
+// tiledOrientation turns a gid's flip bits into a flip and a clockwise angle
+// for DrawSprite, which flips first and rotates after. Tiled flips diagonally
+// first, then horizontally and vertically.
+func tiledOrientation(rawGid uint32) (float64, sdl.RendererFlip) {
+	horizontal := rawGid&TILED_FLIPPED_HORIZONTALLY != 0
+	vertical := rawGid&TILED_FLIPPED_VERTICALLY != 0
+	if rawGid&TILED_FLIPPED_DIAGONALLY == 0 {
+		flip := sdl.FLIP_NONE
+		if horizontal {
+			flip |= sdl.FLIP_HORIZONTAL
+		}
+		if vertical {
+			flip |= sdl.FLIP_VERTICAL
+		}
+		return 0, flip
+	}
+	switch {
+	case horizontal && vertical:
+		return 90, sdl.FLIP_HORIZONTAL
+	case horizontal:
+		return 90, sdl.FLIP_NONE
+	case vertical:
+		return 270, sdl.FLIP_NONE
+	}
+	return 90, sdl.FLIP_VERTICAL
+}
+
+func (i *TiledImporter) addTiles(tiledMap *TiledMap, layer *TiledLayer, tilemap *TilemapComponent) error {
+	for index, rawGid := range layer.data {
+		gid := rawGid & TILED_GID_MASK
+		if gid == 0 {
+			continue
+		}
+		tileset := tiledMap.tilesetFor(gid)
+		if tileset == nil || tileset.columns == 0 {
+			continue
+		}
+
+		localId := gid - tileset.firstGid
+		column, row := int(localId)%tileset.columns, int(localId)/tileset.columns
+		sourceX := tileset.margin + column*(tileset.tileWidth+tileset.spacing)
+		sourceY := tileset.margin + row*(tileset.tileHeight+tileset.spacing)
+
+		// Collision shapes are relative to the tile, which is anchored to the cell's bottom
+		x := (index % tiledMap.width) * tiledMap.tileWidth * i.scale
+		y := ((index/tiledMap.width+1)*tiledMap.tileHeight - tileset.tileHeight) * i.scale
+
+		// Turning a tile that isn't square would move it out of its cell
+		angle, flip := tiledOrientation(rawGid)
+		if angle != 0 && tileset.tileWidth != tileset.tileHeight {
+			return fmt.Errorf("layer %s flips a %dx%d tile of %s diagonally, only square tiles can be", layer.name,
+				tileset.tileWidth, tileset.tileHeight, tileset.name)
+		}
+
+		texture := i.assetManager.GetTexture(tiledTextureId(tileset))
+		source := sdl.Rect{X: int32(sourceX), Y: int32(sourceY), W: int32(tileset.tileWidth), H: int32(tileset.tileHeight)}
+		if err := tilemap.AddRotatedTile(index%tiledMap.width, index/tiledMap.width, int(gid), texture, source, angle, flip); err != nil {
+			return err
+		}
+
+		if tileData, ok := tileset.tiles[localId]; ok {
+			i.addTileColliders(tileData, x, y)
+			i.applyWalkability(tiledMap, index, tileData, true)
+		}
+	}
+	return nil
+}
+
+func (i *TiledImporter) addTileColliders(tile *TiledTile, x, y int) {
+	for _, shape := range tile.shapes {
+		if shape.width <= 0 || shape.height <= 0 {
+			continue
+		}
+		tag := firstNonEmpty(shape.class, tile.properties["collider"], tile.class, "OBSTACLE")
+		colliderX := x + int(shape.x)*i.scale
+		colliderY := y + int(shape.y)*i.scale
+
+		entity := i.manager.AddEntity("tile-collider", TILEMAP_LAYER)
+		entity.AddComponent(NewTransformComponent(Vec2{float64(colliderX), float64(colliderY)}, Vec2{0, 0}, int(shape.width), int(shape.height), Vec2{float64(i.scale), float64(i.scale)}), TRANSFORM_COMPONENT)
+		entity.AddComponent(NewColliderComponent(tag, colliderX, colliderY, int(shape.width)*i.scale, int(shape.height)*i.scale), COLLIDER_COMPONENT)
+	}
+}
+
+func (i *TiledImporter) spawnObjects(layer *TiledLayer, engineLayer LayerType) error {
+	for _, object := range layer.objects {
+		objectLayer, err := parseLayerType(object.properties["layer"], engineLayer)
+		if err != nil {
+			return err
+		}
+		position := Vec2{object.x * float64(i.scale), object.y * float64(i.scale)}
+
+		spawner, ok := i.spawners[object.class]
+		if !ok {
+			spawner = i.spawnDefault
+		}
+		if _, err := spawner(object, position, objectLayer); err != nil {
+			return fmt.Errorf("failed to spawn object %s (%s): %v", object.name, object.class, err)
+		}
+	}
+	return nil
+}
+
+// spawnDefault builds an entity from the object's own properties: "texture"
+// names a loaded texture for its sprite and "collider" a collider tag.
+func (i *TiledImporter) spawnDefault(object TiledObject, position Vec2, layer LayerType) (*Entity, error) {
+	textureId := object.properties["texture"]
+	colliderTag := object.properties["collider"]
+	if textureId == "" && colliderTag == "" {
+		return nil, nil
+	}
+
+	entity := i.manager.AddEntity(firstNonEmpty(object.name, object.class), layer)
+	entity.AddComponent(NewTransformComponent(position, Vec2{0, 0}, int(object.width), int(object.height), Vec2{float64(i.scale), float64(i.scale)}), TRANSFORM_COMPONENT)
+
+	if textureId != "" {
+		texture := i.assetManager.GetTexture(textureId)
+		if texture == nil {
+			return nil, fmt.Errorf("unknown texture %q", textureId)
+		}
+		entity.AddComponent(NewSpriteComponent(texture), SPRITE_COMPONENT)
+	}
+	if colliderTag != "" {
+		entity.AddComponent(NewColliderComponent(colliderTag, int(position.X()), int(position.Y()),
+			int(object.width)*i.scale, int(object.height)*i.scale), COLLIDER_COMPONENT)
+	}
+	return entity, nil
+}
+
+var layerTypeNames = map[string]LayerType{
+	"TILEMAP":    TILEMAP_LAYER,
+	"VEGETATION": VEGETATION_LAYER,
+	"ENEMY":      ENEMY_LAYER,
+	"OBSTACLE":   OBSTACLE_LAYER,
+	"PLAYER":     PLAYER_LAYER,
+	"PROJECTILE": PROJECTILE_LAYER,
+	"UI":         UI_LAYER,
+}
+
+// parseLayerType accepts a layer number or name, with or without the _LAYER
+// suffix ("VEGETATION", "vegetation_layer", "1").
+func parseLayerType(value string, fallback LayerType) (LayerType, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if number, err := strconv.Atoi(value); err == nil {
+		if number < 0 || number >= NUM_LAYERS {
+			return fallback, fmt.Errorf("invalid layer %d", number)
+		}
+		return LayerType(number), nil
+	}
+	if layer, ok := layerTypeNames[strings.TrimSuffix(strings.ToUpper(value), "_LAYER")]; ok {
+		return layer, nil
+	}
+	return fallback, fmt.Errorf("unknown layer %q", value)
+}
@@ -0,0 +1,233 @@
+package engine
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+var (
+	fixtureGround = []uint32{
+		1, 2, 3, 4,
+		1 | TILED_FLIPPED_HORIZONTALLY, 1 | TILED_FLIPPED_VERTICALLY, 1 | TILED_FLIPPED_DIAGONALLY,
+		1 | TILED_FLIPPED_HORIZONTALLY | TILED_FLIPPED_VERTICALLY | TILED_FLIPPED_DIAGONALLY,
+		1, 4, 4, 1,
+	}
+	fixtureDetail  = []uint32{0, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6}
+	fixtureOverlay = []uint32{0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0}
+)
+
+func encodeGids(t *testing.T, gids []uint32, compression string) string {
+	t.Helper()
+	raw := make([]byte, len(gids)*4)
+	for i, gid := range gids {
+		binary.LittleEndian.PutUint32(raw[i*4:], gid)
+	}
+
+	var compressed bytes.Buffer
+	switch compression {
+	case "":
+		compressed.Write(raw)
+	case "zlib":
+		writer := zlib.NewWriter(&compressed)
+		writer.Write(raw)
+		writer.Close()
+	case "gzip":
+		writer := gzip.NewWriter(&compressed)
+		writer.Write(raw)
+		writer.Close()
+	}
+	return base64.StdEncoding.EncodeToString(compressed.Bytes())
+}
+
+func TestDecodeTileData(t *testing.T) {
+	gids := []uint32{0, 1, 42, 7 | TILED_FLIPPED_HORIZONTALLY}
+	tests := []struct {
+		name        string
+		encoding    string
+		compression string
+		text        string
+	}{
+		{"csv", "csv", "", "0, 1,\n42,2147483655\n"},
+		{"base64", "base64", "", encodeGids(t, gids, "")},
+		{"zlib", "base64", "zlib", encodeGids(t, gids, "zlib")},
+		{"gzip", "base64", "gzip", encodeGids(t, gids, "gzip")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := decodeTileData(test.encoding, test.compression, test.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(data, gids) {
+				t.Errorf("decoded %v, want %v", data, gids)
+			}
+		})
+	}
+
+	if _, err := decodeTileData("base64", "zstd", encodeGids(t, gids, "")); err == nil {
+		t.Error("unsupported compression decoded without an error")
+	}
+	if _, err := decodeTileData("hex", "", "00"); err == nil {
+		t.Error("unsupported encoding decoded without an error")
+	}
+}
+
+func TestLoadTiledMap(t *testing.T) {
+	for _, filename := range []string{"testdata/tiled/fixture.tmx", "testdata/tiled/fixture.tmj"} {
+		t.Run(filename, func(t *testing.T) {
+			tiledMap, err := LoadTiledMap(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if width, height := tiledMap.PixelSize(); width != 64 || height != 48 {
+				t.Errorf("pixel size %dx%d, want 64x48", width, height)
+			}
+			if len(tiledMap.tilesets) != 2 {
+				t.Fatalf("%d tilesets, want 2", len(tiledMap.tilesets))
+			}
+			if shapes := tiledMap.tilesets[0].tiles[3].shapes; len(shapes) != 1 || shapes[0].class != "WALL" {
+				t.Errorf("tile 3 shapes %+v, want one WALL", shapes)
+			}
+
+			want := map[string][]uint32{"ground": fixtureGround, "detail": fixtureDetail, "overlay": fixtureOverlay}
+			for _, layer := range tiledMap.layers {
+				if layer.kind != "tilelayer" {
+					continue
+				}
+				if !slices.Equal(layer.data, want[layer.name]) {
+					t.Errorf("layer %s is %v, want %v", layer.name, layer.data, want[layer.name])
+				}
+				delete(want, layer.name)
+			}
+			if len(want) > 0 {
+				t.Errorf("layers %v missing", want)
+			}
+
+			objects := tiledMap.layers[len(tiledMap.layers)-1].objects
+			if len(objects) != 3 || objects[1].Class() != "spawn" || objects[1].Property("facing") != "left" {
+				t.Errorf("objects %+v, want crate, start and marker", objects)
+			}
+		})
+	}
+}
+
+func TestTiledOrientation(t *testing.T) {
+	const (
+		h = TILED_FLIPPED_HORIZONTALLY
+		v = TILED_FLIPPED_VERTICALLY
+		d = TILED_FLIPPED_DIAGONALLY
+	)
+	tests := []struct {
+		bits  uint32
+		angle float64
+		flip  sdl.RendererFlip
+	}{
+		{0, 0, sdl.FLIP_NONE},
+		{h, 0, sdl.FLIP_HORIZONTAL},
+		{v, 0, sdl.FLIP_VERTICAL},
+		{h | v, 0, sdl.FLIP_HORIZONTAL | sdl.FLIP_VERTICAL},
+		{d, 90, sdl.FLIP_VERTICAL},
+		{d | h, 90, sdl.FLIP_NONE},
+		{d | v, 270, sdl.FLIP_NONE},
+		{d | h | v, 90, sdl.FLIP_HORIZONTAL},
+	}
+	for _, test := range tests {
+		angle, flip := tiledOrientation(test.bits | 1)
+		if angle != test.angle || flip != test.flip {
+			t.Errorf("bits %#x gave %v degrees flip %v, want %v degrees flip %v", test.bits, angle, flip, test.angle, test.flip)
+		}
+	}
+}
+
+func TestTiledImport(t *testing.T) {
+	for _, filename := range []string{"testdata/tiled/fixture.tmx", "testdata/tiled/fixture.tmj"} {
+		t.Run(filename, func(t *testing.T) {
+			renderer, manager := newHeadlessManager(t, 64, 48)
+			importer := NewTiledImporter(manager, manager.assetManager, 1)
+			var spawned TiledObject
+			importer.RegisterSpawner("spawn", func(object TiledObject, position Vec2, layer LayerType) (*Entity, error) {
+				spawned = object
+				return manager.AddEntity(object.Name(), layer), nil
+			})
+			if _, err := importer.Import(filename); err != nil {
+				t.Fatal(err)
+			}
+
+			// Both tilesets are called "terrain" but have textures of their own
+			terrain := manager.assetManager.GetTexture("tiled-testdata/tiled/terrain.png")
+			props := manager.assetManager.GetTexture("tiled-testdata/tiled/props.png")
+			if terrain == nil || props == nil || terrain == props {
+				t.Fatalf("tileset textures %v and %v, want two different ones", terrain, props)
+			}
+
+			if spawned.Name() != "start" || manager.GetEntityByName("start") == nil {
+				t.Errorf("spawner got %+v, want the start object", spawned)
+			}
+			crate := manager.GetEntityByName("crate")
+			if crate == nil || crate.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent).colliderTag != "OBSTACLE" {
+				t.Errorf("crate %v, want an OBSTACLE collider", crate)
+			}
+			if manager.GetEntityByName("marker") != nil {
+				t.Error("marker has neither texture nor collider and shouldn't spawn")
+			}
+			walls := 0
+			for _, entity := range manager.Query(COLLIDER_COMPONENT) {
+				if entity.GetComponent(COLLIDER_COMPONENT).(*ColliderComponent).colliderTag == "WALL" {
+					walls++
+				}
+			}
+			if walls != 3 {
+				t.Errorf("%d tile colliders, want one per white tile, 3", walls)
+			}
+			navigation := importer.tilemaps[TILEMAP_LAYER]
+			if navigation.IsWalkable(3, 0) || !navigation.IsWalkable(0, 0) {
+				t.Error("white tiles should block their cells and only them")
+			}
+
+			manager.Update(0)
+			manager.Render(1)
+			if err := renderer.Present(); err != nil {
+				t.Fatal(err)
+			}
+			black := color.RGBA{A: 255}
+			frame := renderer.Frame()
+			// The black pixel at (1, 0) of the red tile shows where each flip put it
+			for _, pixel := range []struct {
+				name string
+				x, y int
+			}{
+				{"unflipped", 1, 0},
+				{"horizontal", 14, 16},
+				{"vertical", 17, 31},
+				{"diagonal", 32, 17},
+				{"all", 63, 30},
+			} {
+				if got := frame.RGBAAt(pixel.x, pixel.y); got != black {
+					t.Errorf("%s flip: pixel %d,%d is %v, want black", pixel.name, pixel.x, pixel.y, got)
+				}
+			}
+			if got := frame.RGBAAt(20, 4); got != (color.RGBA{R: 255, G: 255, A: 255}) {
+				t.Errorf("detail tile is %v, want yellow", got)
+			}
+			if got := frame.RGBAAt(4, 36); got != (color.RGBA{G: 255, A: 255}) {
+				t.Errorf("overlay tile is %v, want green", got)
+			}
+
+			// Rebuilding the map, as a hot reload does, reuses the textures
+			if _, err := importer.Import(filename); err != nil {
+				t.Fatal(err)
+			}
+			if manager.assetManager.GetTexture("tiled-testdata/tiled/terrain.png") != terrain {
+				t.Error("importing again replaced the terrain texture instead of reloading it")
+			}
+		})
+	}
+}
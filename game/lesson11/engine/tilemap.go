@@ -0,0 +1,326 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	TILEMAP_CHUNK_SIZE = 16
+	MAX_BAKED_CHUNKS   = 64
+)
+
+type tile struct {
+	id              int
+	texture         Texture
+	sourceRectangle sdl.Rect
+	angle           float64
+	flip            sdl.RendererFlip
+	next            int32
+}
+
+type tilemapChunk struct {
+	texture   Texture
+	dirty     bool
+	lastDrawn uint64
+}
+
+// TilemapComponent draws a whole grid of tiles, baked into render targets of
+// TILEMAP_CHUNK_SIZE x TILEMAP_CHUNK_SIZE cells. Only chunks the camera sees
+// are baked and drawn.
+type TilemapComponent struct {
+	owner         *Entity
+	width         int
+	height        int
+	tileWidth     int
+	tileHeight    int
+	scale         int
+	tiles         []tile
+	first         []int32
+	last          []int32
+	maxTileWidth  int
+	maxTileHeight int
+	chunks        []tilemapChunk
+	chunksX       int
+	chunksY       int
+	baked         int
+	frame         uint64
+	walkable      []bool
+	costs         []float64
+	version       uint64
+	drawing       uint64
+}
+
+func NewTilemapComponent(width, height, tileWidth, tileHeight, scale int) *TilemapComponent {
+	chunksX := (width + TILEMAP_CHUNK_SIZE - 1) / TILEMAP_CHUNK_SIZE
+	chunksY := (height + TILEMAP_CHUNK_SIZE - 1) / TILEMAP_CHUNK_SIZE
+	walkable := make([]bool, width*height)
+	costs := make([]float64, width*height)
+	for i := range walkable {
+		walkable[i], costs[i] = true, 1
+	}
+	return &TilemapComponent{
+		width:         width,
+		height:        height,
+		tileWidth:     tileWidth,
+		tileHeight:    tileHeight,
+		scale:         scale,
+		first:         make([]int32, width*height),
+		last:          make([]int32, width*height),
+		walkable:      walkable,
+		costs:         costs,
+		maxTileWidth:  tileWidth,
+		maxTileHeight: tileHeight,
+		chunks:        make([]tilemapChunk, chunksX*chunksY),
+		chunksX:       chunksX,
+		chunksY:       chunksY,
+	}
+}
+
+func (c *TilemapComponent) SetOwner(e *Entity) {
+	c.owner = e
+}
+
+func (c *TilemapComponent) Initialize() {}
+
+func (c *TilemapComponent) Update(deltaTime float64) {}
+
+// AddTile stacks a tile on the cell at column x, row y. Tiles bigger than a
+// cell are anchored to the cell's bottom left corner, as Tiled does. The id is
+// whatever the map format numbers its tiles with, kept for TileIds.
+func (c *TilemapComponent) AddTile(x, y, id int, texture Texture, sourceRectangle sdl.Rect, flip sdl.RendererFlip) error {
+	return c.AddRotatedTile(x, y, id, texture, sourceRectangle, 0, flip)
+}
+
+// AddRotatedTile is AddTile for a tile turned angle degrees clockwise after
+// flipping.
+func (c *TilemapComponent) AddRotatedTile(x, y, id int, texture Texture, sourceRectangle sdl.Rect, angle float64, flip sdl.RendererFlip) error {
+	if !c.Contains(x, y) {
+		return fmt.Errorf("tile %d,%d is outside the %dx%d tilemap", x, y, c.width, c.height)
+	}
+
+	// A cell's tiles are chained through next, one-based so 0 ends the chain
+	index := y*c.width + x
+	c.tiles = append(c.tiles, tile{id, texture, sourceRectangle, angle, flip, 0})
+	added := int32(len(c.tiles))
+	if c.last[index] == 0 {
+		c.first[index] = added
+	} else {
+		c.tiles[c.last[index]-1].next = added
+	}
+	c.last[index] = added
+	c.drawing++
+	c.maxTileWidth = max(c.maxTileWidth, int(sourceRectangle.W))
+	c.maxTileHeight = max(c.maxTileHeight, int(sourceRectangle.H))
+
+	// Oversized tiles spill into the chunks above and to the right
+	for cy := (y - c.overflowRows()) / TILEMAP_CHUNK_SIZE; cy <= y/TILEMAP_CHUNK_SIZE; cy++ {
+		for cx := x / TILEMAP_CHUNK_SIZE; cx <= min(x+c.overflowColumns(), c.width-1)/TILEMAP_CHUNK_SIZE; cx++ {
+			if cy >= 0 {
+				c.chunks[cy*c.chunksX+cx].dirty = true
+			}
+		}
+	}
+	return nil
+}
+
+func (c *TilemapComponent) overflowColumns() int {
+	return (c.maxTileWidth+c.tileWidth-1)/c.tileWidth - 1
+}
+
+func (c *TilemapComponent) overflowRows() int {
+	return (c.maxTileHeight+c.tileHeight-1)/c.tileHeight - 1
+}
+
+func (c *TilemapComponent) PixelSize() (int, int) {
+	return c.width * c.tileWidth * c.scale, c.height * c.tileHeight * c.scale
+}
+
+// Size is the tilemap's size in cells.
+func (c *TilemapComponent) Size() (int, int) {
+	return c.width, c.height
+}
+
+func (c *TilemapComponent) Contains(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.width && y < c.height
+}
+
+// TileIds lists the ids of the tiles stacked on a cell, bottom first.
+func (c *TilemapComponent) TileIds(x, y int) []int {
+	if !c.Contains(x, y) {
+		return nil
+	}
+	var ids []int
+	for i := c.first[y*c.width+x]; i != 0; i = c.tiles[i-1].next {
+		ids = append(ids, c.tiles[i-1].id)
+	}
+	return ids
+}
+
+// CellAt is the cell under a world position.
+func (c *TilemapComponent) CellAt(position Vec2) (int, int, bool) {
+	x := int(math.Floor(position.X() / float64(c.tileWidth*c.scale)))
+	y := int(math.Floor(position.Y() / float64(c.tileHeight*c.scale)))
+	return x, y, c.Contains(x, y)
+}
+
+// CellCenter is the world position of the middle of a cell.
+func (c *TilemapComponent) CellCenter(x, y int) Vec2 {
+	return Vec2{(float64(x) + 0.5) * float64(c.tileWidth*c.scale), (float64(y) + 0.5) * float64(c.tileHeight*c.scale)}
+}
+
+// IsWalkable reports whether entities can move through a cell; cells
+// outside the tilemap never are.
+func (c *TilemapComponent) IsWalkable(x, y int) bool {
+	return c.Contains(x, y) && c.walkable[y*c.width+x]
+}
+
+func (c *TilemapComponent) SetWalkable(x, y int, walkable bool) {
+	if c.Contains(x, y) {
+		c.walkable[y*c.width+x] = walkable
+		c.version++
+	}
+}
+
+// Cost is how many times more it costs to cross a cell than plain ground.
+func (c *TilemapComponent) Cost(x, y int) float64 {
+	if !c.Contains(x, y) {
+		return 1
+	}
+	return c.costs[y*c.width+x]
+}
+
+// SetCost sets a cell's cost, at least 1 so that path searches can estimate
+// distances by plain ground.
+func (c *TilemapComponent) SetCost(x, y int, cost float64) {
+	if c.Contains(x, y) {
+		c.costs[y*c.width+x] = max(cost, 1)
+		c.version++
+	}
+}
+
+// Version changes whenever walkability or costs do, so paths found on the
+// old grid can be thrown away.
+func (c *TilemapComponent) Version() uint64 {
+	return c.version
+}
+
+// DrawingVersion changes whenever the tiles or how they look may have, so
+// anything drawn from them should be drawn again.
+func (c *TilemapComponent) DrawingVersion() uint64 {
+	return c.drawing
+}
+
+// Invalidate drops every baked chunk, e.g. after SDL reports that the
+// contents of render targets were lost.
+func (c *TilemapComponent) Invalidate() {
+	c.drawing++
+	for i := range c.chunks {
+		c.releaseChunk(&c.chunks[i])
+	}
+}
+
+func (c *TilemapComponent) releaseChunk(chunk *tilemapChunk) {
+	if chunk.texture != nil {
+		chunk.texture.Destroy()
+		chunk.texture = nil
+		c.baked--
+	}
+}
+
+func (c *TilemapComponent) Render(renderer Renderer) {
+	c.frame++
+	camera := c.owner.manager.camera
+	view := camera.View()
+	chunkWidth := TILEMAP_CHUNK_SIZE * c.tileWidth * c.scale
+	chunkHeight := TILEMAP_CHUNK_SIZE * c.tileHeight * c.scale
+
+	firstX := max(int(view.X)/chunkWidth, 0)
+	firstY := max(int(view.Y)/chunkHeight, 0)
+	lastX := min(int(view.X+view.W)/chunkWidth, c.chunksX-1)
+	lastY := min(int(view.Y+view.H)/chunkHeight, c.chunksY-1)
+
+	for cy := firstY; cy <= lastY; cy++ {
+		for cx := firstX; cx <= lastX; cx++ {
+			chunk := &c.chunks[cy*c.chunksX+cx]
+			if chunk.texture == nil || chunk.dirty {
+				if err := c.bake(renderer, chunk, cx, cy); err != nil {
+					fmt.Println(err)
+					continue
+				}
+			}
+			chunk.lastDrawn = c.frame
+
+			source := sdl.Rect{W: int32(TILEMAP_CHUNK_SIZE * c.tileWidth), H: int32(TILEMAP_CHUNK_SIZE * c.tileHeight)}
+			destination := camera.ToScreen(sdl.Rect{
+				X: int32(cx * chunkWidth),
+				Y: int32(cy * chunkHeight),
+				W: int32(chunkWidth),
+				H: int32(chunkHeight),
+			})
+			renderer.DrawSprite(chunk.texture, source, destination, 0, nil, sdl.FLIP_NONE)
+		}
+	}
+
+	c.evict()
+}
+
+// bake draws the chunk's tiles at their native size into its render target.
+func (c *TilemapComponent) bake(renderer Renderer, chunk *tilemapChunk, cx, cy int) error {
+	if chunk.texture == nil {
+		texture, err := renderer.CreateTexture(int32(TILEMAP_CHUNK_SIZE*c.tileWidth), int32(TILEMAP_CHUNK_SIZE*c.tileHeight), true)
+		if err != nil {
+			return fmt.Errorf("failed to create tilemap chunk: %v", err)
+		}
+		chunk.texture = texture
+		c.baked++
+	}
+
+	if err := renderer.SetTarget(chunk.texture); err != nil {
+		return fmt.Errorf("failed to bake tilemap chunk: %v", err)
+	}
+	defer renderer.SetTarget(nil)
+	renderer.Clear(sdl.Color{})
+
+	originX := cx * TILEMAP_CHUNK_SIZE
+	originY := cy * TILEMAP_CHUNK_SIZE
+	lastX := min(originX+TILEMAP_CHUNK_SIZE-1, c.width-1)
+	lastY := min(originY+TILEMAP_CHUNK_SIZE+c.overflowRows()-1, c.height-1)
+	for y := originY; y <= lastY; y++ {
+		for x := max(originX-c.overflowColumns(), 0); x <= lastX; x++ {
+			for i := c.first[y*c.width+x]; i != 0; i = c.tiles[i-1].next {
+				t := &c.tiles[i-1]
+				destination := sdl.Rect{
+					X: int32((x - originX) * c.tileWidth),
+					Y: int32((y-originY+1)*c.tileHeight) - t.sourceRectangle.H,
+					W: t.sourceRectangle.W,
+					H: t.sourceRectangle.H,
+				}
+				renderer.DrawSprite(t.texture, t.sourceRectangle, destination, t.angle, nil, t.flip)
+			}
+		}
+	}
+
+	chunk.dirty = false
+	return nil
+}
+
+// evict frees the chunks drawn longest ago once more than MAX_BAKED_CHUNKS
+// are held, so scrolling over a large map doesn't keep every chunk in VRAM.
+func (c *TilemapComponent) evict() {
+	for c.baked > MAX_BAKED_CHUNKS {
+		oldest := -1
+		for i := range c.chunks {
+			chunk := &c.chunks[i]
+			if chunk.texture != nil && chunk.lastDrawn != c.frame && (oldest < 0 || chunk.lastDrawn < c.chunks[oldest].lastDrawn) {
+				oldest = i
+			}
+		}
+		if oldest < 0 {
+			return
+		}
+		c.releaseChunk(&c.chunks[oldest])
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func dirtyChunks(tilemap *TilemapComponent) []int {
+	dirty := []int{}
+	for i, chunk := range tilemap.chunks {
+		if chunk.dirty {
+			dirty = append(dirty, i)
+			tilemap.chunks[i].dirty = false
+		}
+	}
+	return dirty
+}
+
+func TestTilemapDirtyChunks(t *testing.T) {
+	tilemap := NewTilemapComponent(40, 40, 16, 16, 1)
+	if tilemap.chunksX != 3 || tilemap.chunksY != 3 {
+		t.Fatalf("%dx%d chunks, want 3x3", tilemap.chunksX, tilemap.chunksY)
+	}
+
+	if err := tilemap.AddTile(17, 16, 1, nil, sdl.Rect{W: 16, H: 16}, sdl.FLIP_NONE); err != nil {
+		t.Fatal(err)
+	}
+	if dirty := dirtyChunks(tilemap); !slices.Equal(dirty, []int{4}) {
+		t.Errorf("dirty chunks %v, want the middle one", dirty)
+	}
+
+	// A tile twice the cell size reaches into the chunks above and right
+	if err := tilemap.AddTile(15, 16, 1, nil, sdl.Rect{W: 32, H: 32}, sdl.FLIP_NONE); err != nil {
+		t.Fatal(err)
+	}
+	if dirty := dirtyChunks(tilemap); !slices.Equal(dirty, []int{0, 1, 3, 4}) {
+		t.Errorf("dirty chunks %v, want 0 1 3 4", dirty)
+	}
+
+	// Tiles on the right edge don't spill past the map
+	if err := tilemap.AddTile(39, 32, 1, nil, sdl.Rect{W: 16, H: 16}, sdl.FLIP_NONE); err != nil {
+		t.Fatal(err)
+	}
+	if dirty := dirtyChunks(tilemap); !slices.Equal(dirty, []int{5, 8}) {
+		t.Errorf("dirty chunks %v, want 5 8", dirty)
+	}
+
+	if err := tilemap.AddTile(40, 0, 1, nil, sdl.Rect{W: 16, H: 16}, sdl.FLIP_NONE); err == nil {
+		t.Error("added a tile outside the map")
+	}
+}
+
+func TestTilemapStacks(t *testing.T) {
+	tilemap := NewTilemapComponent(2, 2, 16, 16, 1)
+	for _, tile := range []struct{ x, y, sourceX int }{{1, 0, 1}, {0, 1, 2}, {1, 0, 3}, {1, 0, 4}} {
+		if err := tilemap.AddTile(tile.x, tile.y, tile.sourceX, nil, sdl.Rect{X: int32(tile.sourceX), W: 16, H: 16}, sdl.FLIP_NONE); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for index, want := range [][]int32{{}, {1, 3, 4}, {2}, {}} {
+		stack := []int32{}
+		for i := tilemap.first[index]; i != 0; i = tilemap.tiles[i-1].next {
+			stack = append(stack, tilemap.tiles[i-1].sourceRectangle.X)
+		}
+		if !slices.Equal(stack, want) {
+			t.Errorf("cell %d stacks %v, want %v", index, stack, want)
+		}
+	}
+}
+
+func TestTilemapEviction(t *testing.T) {
+	renderer, manager := newHeadlessManager(t, 160, 80)
+	tilemap := NewTilemapComponent(10*TILEMAP_CHUNK_SIZE, 10*TILEMAP_CHUNK_SIZE, 1, 1, 1)
+	manager.AddEntity("map", TILEMAP_LAYER).AddComponent(tilemap, TILEMAP_COMPONENT)
+
+	look := func(center Vec2) {
+		manager.camera.center, manager.camera.previous = center, center
+		tilemap.Render(renderer)
+	}
+	// Six rows of ten chunks, counting the row the view's bottom edge touches
+	look(Vec2{80, 40})
+	if tilemap.baked != 60 {
+		t.Fatalf("%d chunks baked, want 60", tilemap.baked)
+	}
+
+	// Scrolling to the bottom half bakes 50 more and evicts the oldest
+	look(Vec2{80, 120})
+	if tilemap.baked != MAX_BAKED_CHUNKS {
+		t.Errorf("%d chunks baked, want %d", tilemap.baked, MAX_BAKED_CHUNKS)
+	}
+	for i, chunk := range tilemap.chunks {
+		if visible := i >= 5*tilemap.chunksX; visible && chunk.texture == nil {
+			t.Errorf("visible chunk %d was evicted", i)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const ASSET_POLL_INTERVAL = 0.5
+
+// AssetWatcher polls a directory tree for files whose modification time or
+// size changed. Polling needs no platform support and is cheap for a tree the
+// size of the game's assets.
+type AssetWatcher struct {
+	root    string
+	elapsed float64
+	files   map[string]fileStamp
+}
+
+type fileStamp struct {
+	modified time.Time
+	size     int64
+}
+
+func NewAssetWatcher(root string) *AssetWatcher {
+	w := &AssetWatcher{root: root}
+	w.files = w.scan()
+	return w
+}
+
+// Update returns the files changed or added since the last poll, at most
+// once every ASSET_POLL_INTERVAL seconds.
+func (w *AssetWatcher) Update(deltaTime float64) []string {
+	w.elapsed += deltaTime
+	if w.elapsed < ASSET_POLL_INTERVAL {
+		return nil
+	}
+	w.elapsed = 0
+	return w.Poll()
+}
+
+func (w *AssetWatcher) Poll() []string {
+	files := w.scan()
+	var changed []string
+	for filename, stamp := range files {
+		if previous, ok := w.files[filename]; !ok || previous != stamp {
+			changed = append(changed, filename)
+		}
+	}
+	w.files = files
+	sort.Strings(changed)
+	return changed
+}
+
+func (w *AssetWatcher) scan() map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	// A file vanishing mid-walk, e.g. while an editor saves it, is skipped
+	// and picked up on the next poll
+	filepath.WalkDir(w.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			files[filepath.Clean(path)] = fileStamp{info.ModTime(), info.Size()}
+		}
+		return nil
+	})
+	return files
+}
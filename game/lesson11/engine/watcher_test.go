@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAssetWatcher(t *testing.T) {
+	root := t.TempDir()
+	first := filepath.Join(root, "first.lua")
+	second := filepath.Join(root, "maps", "second.tmx")
+	if err := os.WriteFile(first, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	watcher := NewAssetWatcher(root)
+
+	if err := os.WriteFile(first, []byte("ab"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(second), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("c"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Polls wait for ASSET_POLL_INTERVAL to pass
+	if changed := watcher.Update(ASSET_POLL_INTERVAL / 2); changed != nil {
+		t.Errorf("polled early: %v", changed)
+	}
+	if changed := watcher.Update(ASSET_POLL_INTERVAL / 2); !slices.Equal(changed, []string{first, second}) {
+		t.Errorf("changed %v, want %v", changed, []string{first, second})
+	}
+	if changed := watcher.Poll(); len(changed) != 0 {
+		t.Errorf("changed %v with nothing touched", changed)
+	}
+}
@@ -1,14 +1,28 @@
 package main
 
-import "engine/lesson11/engine"
+import (
+	"flag"
+
+	"engine/lesson11/engine"
+)
 
 func main() {
-	game := engine.Game{}
+	vsync := flag.Bool("vsync", true, "pace frames to the display's refresh rate")
+	uncapped := flag.Bool("uncapped", false, "render as many frames as possible")
+	headless := flag.Bool("headless", false, "run without a window, drawing into memory")
+	frames := flag.String("frames", "", "directory to write headless frames to as PNG")
+	maxFrames := flag.Int("max-frames", 0, "stop after this many frames, 0 runs until quit")
+	level := flag.Int("level", 0, "skip the menu and start at this level")
+	dev := flag.Bool("dev", false, "reload assets, maps and level scripts when they change")
+	flag.Parse()
+
+	game := engine.Game{Options: engine.GameOptions{VSync: *vsync && !*headless, Uncapped: *uncapped,
+		Headless: *headless, FrameDirectory: *frames, StartLevel: *level, HotReload: *dev}}
 	if err := game.Initialize(); err != nil {
 		panic(err)
 	}
 
-	for game.IsRunning() {
+	for frame := 0; game.IsRunning() && (*maxFrames == 0 || frame < *maxFrames); frame++ {
 		game.ProcessInput()
 		game.Update()
 		game.Render()